@@ -3,8 +3,11 @@ package device
 import (
 	"context"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/odaacabeef/beefdown/midi"
 	"github.com/odaacabeef/beefdown/sequence"
 )
 
@@ -12,20 +15,40 @@ const deviceName = "beefdown"
 const syncDeviceName = "beefdown-sync"
 
 type Device struct {
-	bpm   float64
-	loop  bool
-	sync  string
-	beat  time.Duration
-	state state
-
-	clock *Clock
+	bpm    float64
+	loop   bool
+	chase  bool
+	launch string // Launch quantization: bar, beat or step
+	sync   string
+	beat   time.Duration
+	state  state
+
+	// Followers estimate the leader's tempo from its timing clock. With
+	// smoothing, ticks come from a clock locked to the leader's: received and
+	// generated count the leader's clocks and the ticks played.
+	tracker   midi.Tracker
+	smooth    bool
+	received  atomic.Int64
+	generated atomic.Int64
+
+	// bpmMu guards bpm and beat, which change while playing, and the times of
+	// tap tempo taps
+	bpmMu sync.RWMutex
+	taps  []time.Time
+
+	// clockMu guards clock and its tick callback, which are started and
+	// stopped from the playback, UI and MIDI input goroutines
+	clock   *Clock
+	tick    func()
+	clockMu sync.Mutex
 
 	ctx     context.Context
 	CancelF context.CancelFunc
 
-	PlaySub  sub
-	StopSub  sub
-	ClockSub sub
+	PlaySub   sub
+	StopSub   sub
+	ClockSub  sub
+	LaunchSub sub // Published when a queued playable launches
 
 	errorsCh chan error
 
@@ -37,8 +60,44 @@ type Device struct {
 	syncCancelF func()
 	listening   bool
 
+	// Followers pause when no timing clock arrives for syncTimeout while
+	// playing, and continue when it comes back
+	syncTimeout time.Duration
+	syncTimer   *time.Timer
+	syncMu      sync.Mutex
+	lost        atomic.Bool
+
 	// Current playback parameters
 	currentPlayable sequence.Playable
+
+	// fill is the fill toggle for notes with [fill] and [!fill] conditions
+	fill atomic.Bool
+
+	// position is the number of clock ticks played since playback started.
+	// seek is a position to move to on the next clock tick, or -1.
+	position atomic.Int64
+	seek     atomic.Int64
+
+	// queued is the playable to launch at the next launch boundary
+	queued  sequence.Playable
+	queueMu sync.Mutex
+
+	// mutes is the live mute and solo state of parts and channels
+	mutes mutes
+
+	// layers are playables looping alongside the one playing. layerID
+	// numbers their clock subscriptions.
+	layers   []*layer
+	layerID  int
+	layersMu sync.Mutex
+
+	// transport is the number of clock ticks since playback started, which
+	// layers launch from
+	transport atomic.Int64
+
+	// primary holds the notes the playable playing has sounding, so launches
+	// and seeks end them without ending the notes of layers
+	primary midi.Notes
 }
 
 // New creates a new Device
@@ -76,10 +135,15 @@ func New(sync, voiceOut, syncIn, syncOut string) (*Device, error) {
 		ClockSub: sub{
 			ch: make(map[string]chan struct{}),
 		},
+		LaunchSub: sub{
+			ch: make(map[string]chan struct{}),
+		},
 		trackOut: trackOut,
 		syncOut:  nil, // No sync output for regular devices
 	}
 
+	d.seek.Store(-1)
+
 	switch sync {
 	case "follower":
 		var syncInPort *MidiInput
@@ -122,3 +186,13 @@ func New(sync, voiceOut, syncIn, syncOut string) (*Device, error) {
 func (d *Device) ErrorsCh() chan error {
 	return d.errorsCh
 }
+
+// Fill reports whether fill is on
+func (d *Device) Fill() bool {
+	return d.fill.Load()
+}
+
+// ToggleFill turns fill on or off. It applies from the next step.
+func (d *Device) ToggleFill() {
+	d.fill.Store(!d.fill.Load())
+}
@@ -0,0 +1,61 @@
+package device
+
+import "github.com/odaacabeef/beefdown/sequence"
+
+// Queue queues a playable to launch in place of the one playing, at the next
+// boundary of the launch quantization. Queueing nil empties the queue.
+func (d *Device) Queue(playable sequence.Playable) {
+	d.queueMu.Lock()
+	defer d.queueMu.Unlock()
+	d.queued = playable
+}
+
+// Queued returns the playable queued to launch, if any
+func (d *Device) Queued() sequence.Playable {
+	d.queueMu.Lock()
+	defer d.queueMu.Unlock()
+	return d.queued
+}
+
+// boundary reports whether a tick of the playing arrangement's timeline, at
+// a position of playback, is a launch boundary. Bars are 4 beats from the
+// start of playback.
+func (d *Device) boundary(a *sequence.Arrangement, events []sequence.Event, position, tick int) bool {
+	switch d.launch {
+	case "beat":
+		return position%24 == 0
+	case "step":
+		return len(events) > 0 && events[0].Tick == tick &&
+			events[0].Type == sequence.StepEvent && events[0].Playable == a
+	}
+	return position%96 == 0
+}
+
+// launchDue returns the arrangement of the queued playable on a launch
+// boundary, taking it off the queue
+func (d *Device) launchDue(boundary bool) *sequence.Arrangement {
+	d.queueMu.Lock()
+	defer d.queueMu.Unlock()
+	if d.queued == nil || !boundary {
+		return nil
+	}
+
+	var next *sequence.Arrangement
+	switch p := d.queued.(type) {
+	case *sequence.Arrangement:
+		next = p
+	case *sequence.Part:
+		next = p.Arrangement()
+	}
+	d.queued = nil
+	return next
+}
+
+// switchTo stops the arrangement playing and starts the next from the
+// beginning of its passes, without stopping the clock
+func (d *Device) switchTo(a, next *sequence.Arrangement) {
+	a.ClearSteps()
+	d.endPrimary()
+	next.ResetPasses()
+	d.LaunchSub.Pub()
+}
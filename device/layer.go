@@ -0,0 +1,204 @@
+package device
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/odaacabeef/beefdown/midi"
+	"github.com/odaacabeef/beefdown/sequence"
+)
+
+// layer is a playable looping alongside the one playing, against the same
+// clock. Each layer plays a copy of its playable from its own goroutine, so
+// its parts keep their own passes, and it keeps playing after the playable
+// playing ends.
+type layer struct {
+	playable sequence.Playable
+	a        *sequence.Arrangement
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	// Notes the layer has sounding, by channel and note
+	sounding map[[2]byte]bool
+	mu       sync.Mutex
+}
+
+// ToggleLayer starts playing a playable alongside the one playing, from the
+// next launch boundary, or stops it if it's already playing as a layer
+func (d *Device) ToggleLayer(playable sequence.Playable) {
+	d.layersMu.Lock()
+	defer d.layersMu.Unlock()
+
+	for _, l := range d.layers {
+		if l.playable == playable && l.ctx.Err() == nil {
+			l.cancel()
+			return
+		}
+	}
+	if d.ctx == nil || d.ctx.Err() != nil {
+		return
+	}
+
+	l := &layer{playable: playable, sounding: map[[2]byte]bool{}, done: make(chan struct{})}
+	switch p := playable.(type) {
+	case *sequence.Arrangement:
+		l.a = p.Layer()
+	case *sequence.Part:
+		l.a = p.Arrangement().Layer()
+	}
+	l.ctx, l.cancel = context.WithCancel(d.ctx)
+	d.layerID++
+	d.layers = append(d.layers, l)
+	go d.playLayer(l, fmt.Sprintf("layer %d", d.layerID))
+}
+
+// Layered reports whether a playable is playing as a layer
+func (d *Device) Layered(playable sequence.Playable) bool {
+	d.layersMu.Lock()
+	defer d.layersMu.Unlock()
+	return slices.ContainsFunc(d.layers, func(l *layer) bool {
+		return l.playable == playable && l.ctx.Err() == nil
+	})
+}
+
+// playLayer loops a layer's arrangement until the layer or playback is
+// stopped. Layers start on a beat with beat launch quantization, and on a bar
+// otherwise, counted from the start of the transport.
+func (d *Device) playLayer(l *layer, name string) {
+	// Buffered so ticks aren't dropped while messages are being sent
+	clockSub := make(chan struct{}, 96)
+	d.ClockSub.Sub(name, clockSub)
+	defer func() {
+		d.ClockSub.Unsub(name)
+		d.endLayer(l)
+		d.layersMu.Lock()
+		d.layers = slices.DeleteFunc(d.layers, func(o *layer) bool { return o == l })
+		d.layersMu.Unlock()
+		close(l.done)
+	}()
+
+	every := int64(96)
+	if d.launch == "beat" {
+		every = 24
+	}
+	for {
+		if !d.waitTick(l.ctx, clockSub) {
+			return
+		}
+		if (d.transport.Load()-1)%every == 0 {
+			break
+		}
+	}
+
+	l.a.ResetPasses()
+	send := l.send(d)
+	// The clock tick of the launch boundary plays the first tick
+	received := true
+	for {
+		// Generated parts with regen may change at the start of each pass
+		if err := l.a.Regenerate(); err != nil {
+			d.errorsCh <- err
+		}
+		timeline := l.a.Compile()
+		if timeline.Ticks == 0 {
+			return
+		}
+
+		pending := map[int][]message{}
+		events := timeline.Events
+		for tick := range timeline.Ticks {
+			if !received && !d.waitTick(l.ctx, clockSub) {
+				return
+			}
+			received = false
+
+			d.endMuted()
+			for len(events) > 0 && events[0].Tick == tick {
+				d.handleEvent(events[0], pending, send)
+				events = events[1:]
+			}
+			for _, m := range pending[tick] {
+				send(m)
+			}
+			delete(pending, tick)
+		}
+	}
+}
+
+// send returns a function sending messages for the layer, keeping track of
+// the notes it has sounding
+func (l *layer) send(d *Device) func(message) {
+	return func(m message) {
+		l.mu.Lock()
+		switch b := m.bytes; {
+		case midi.IsNoteOff(b):
+			delete(l.sounding, [2]byte{b[0] & 0x0F, b[1]})
+		case midi.IsNoteOn(b):
+			l.sounding[[2]byte{b[0] & 0x0F, b[1]}] = true
+		}
+		l.mu.Unlock()
+		d.send(m)
+	}
+}
+
+// has reports whether the layer has a note sounding on a channel (0-15)
+func (l *layer) has(channel, note byte) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.sounding[[2]byte{channel, note}]
+}
+
+// endLayer clears the steps of a stopped layer and ends its sounding notes
+func (d *Device) endLayer(l *layer) {
+	l.a.ClearSteps()
+	l.mu.Lock()
+	notes := slices.SortedFunc(maps.Keys(l.sounding), func(a, b [2]byte) int {
+		return cmp.Or(cmp.Compare(a[0], b[0]), cmp.Compare(a[1], b[1]))
+	})
+	clear(l.sounding)
+	l.mu.Unlock()
+	for _, n := range notes {
+		d.send(message{bytes: midi.NoteOff(n[0], n[1], 0)})
+	}
+}
+
+// waitLayers keeps the transport running after the playable playing ends,
+// until every layer has stopped or playback is cancelled
+func (d *Device) waitLayers() {
+	d.endPrimary()
+	for {
+		d.layersMu.Lock()
+		var done chan struct{}
+		if len(d.layers) > 0 {
+			done = d.layers[0].done
+		}
+		d.layersMu.Unlock()
+		if done == nil {
+			return
+		}
+
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-done:
+		}
+	}
+}
+
+// clearLayers stops every layer when playback stops, and waits for them to
+// end
+func (d *Device) clearLayers() {
+	d.layersMu.Lock()
+	layers := slices.Clone(d.layers)
+	d.layersMu.Unlock()
+	for _, l := range layers {
+		l.cancel()
+		<-l.done
+	}
+}
@@ -11,6 +11,8 @@ import (
 	"fmt"
 	"sync"
 	"unsafe"
+
+	"github.com/odaacabeef/beefdown/midi"
 )
 
 // Global registry for MIDI input callbacks
@@ -24,6 +26,9 @@ var (
 type MidiOutput struct {
 	id C.int32_t
 	mu sync.Mutex
+
+	// notes sounding from the messages sent
+	notes midi.Notes
 }
 
 // MidiInput represents a MIDI input port
@@ -73,6 +78,7 @@ func (m *MidiOutput) Send(bytes []byte) error {
 	if result != 0 {
 		return fmt.Errorf("failed to send MIDI message")
 	}
+	m.notes.Track(bytes)
 
 	return nil
 }
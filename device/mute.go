@@ -0,0 +1,180 @@
+package device
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+
+	"github.com/odaacabeef/beefdown/midi"
+	"github.com/odaacabeef/beefdown/sequence"
+)
+
+// message is a message to send, and the name of the part it's from if any
+type message struct {
+	part  string
+	bytes []byte
+}
+
+// mutes is the mute and solo state of parts, by name, and of channels. Note
+// ons that aren't audible are dropped as they're sent. Note offs and other
+// messages are always sent.
+type mutes struct {
+	mu           sync.Mutex
+	parts        map[string]bool
+	soloParts    map[string]bool
+	channels     [16]bool
+	soloChannels [16]bool
+
+	// Parts playing each sounding note, by channel and note, in the order
+	// they struck it. A part is listed once for each time it struck the note,
+	// so notes are ended when every part playing them is muted.
+	sounding map[[2]byte][]string
+	changed  bool
+}
+
+// audible reports whether notes of a part on a channel (0-15) are sent
+func (m *mutes) audible(part string, channel uint8) bool {
+	if m.parts[part] || m.channels[channel] {
+		return false
+	}
+	if len(m.soloParts) == 0 && !slices.Contains(m.soloChannels[:], true) {
+		return true
+	}
+	return m.soloParts[part] || m.soloChannels[channel]
+}
+
+// toggle mutes or solos parts in a set of parts. If any of them aren't in
+// the set they're all added, otherwise they're all removed.
+func toggle(set map[string]bool, parts []*sequence.Part) map[string]bool {
+	if set == nil {
+		set = map[string]bool{}
+	}
+	add := slices.ContainsFunc(parts, func(p *sequence.Part) bool {
+		return !set[p.Name()]
+	})
+	for _, p := range parts {
+		if add {
+			set[p.Name()] = true
+		} else {
+			delete(set, p.Name())
+		}
+	}
+	return set
+}
+
+// ToggleMute mutes or unmutes parts. Notes they have sounding are ended.
+func (d *Device) ToggleMute(parts ...*sequence.Part) {
+	d.mutes.mu.Lock()
+	defer d.mutes.mu.Unlock()
+	d.mutes.parts = toggle(d.mutes.parts, parts)
+	d.mutes.changed = true
+}
+
+// ToggleSolo solos or unsolos parts. While any part or channel is soloed,
+// only those soloed are audible.
+func (d *Device) ToggleSolo(parts ...*sequence.Part) {
+	d.mutes.mu.Lock()
+	defer d.mutes.mu.Unlock()
+	d.mutes.soloParts = toggle(d.mutes.soloParts, parts)
+	d.mutes.changed = true
+}
+
+// ToggleChannelMute mutes or unmutes a channel (1-16)
+func (d *Device) ToggleChannelMute(channel uint8) {
+	d.mutes.mu.Lock()
+	defer d.mutes.mu.Unlock()
+	d.mutes.channels[(channel-1)&0x0F] = !d.mutes.channels[(channel-1)&0x0F]
+	d.mutes.changed = true
+}
+
+// ToggleChannelSolo solos or unsolos a channel (1-16)
+func (d *Device) ToggleChannelSolo(channel uint8) {
+	d.mutes.mu.Lock()
+	defer d.mutes.mu.Unlock()
+	d.mutes.soloChannels[(channel-1)&0x0F] = !d.mutes.soloChannels[(channel-1)&0x0F]
+	d.mutes.changed = true
+}
+
+// Audible reports whether a part's notes are sent, given the mute and solo
+// state of it and its channel
+func (d *Device) Audible(part *sequence.Part) bool {
+	d.mutes.mu.Lock()
+	defer d.mutes.mu.Unlock()
+	return d.mutes.audible(part.Name(), (part.Channel()-1)&0x0F)
+}
+
+// send sends a message from playback unless it's a note on that isn't
+// audible
+func (d *Device) send(m message) {
+	d.mutes.mu.Lock()
+	switch b := m.bytes; {
+	case midi.IsNoteOff(b):
+		d.mutes.release(m.part, [2]byte{b[0] & 0x0F, b[1]})
+	case midi.IsNoteOn(b):
+		if !d.mutes.audible(m.part, b[0]&0x0F) {
+			d.mutes.mu.Unlock()
+			return
+		}
+		if d.mutes.sounding == nil {
+			d.mutes.sounding = map[[2]byte][]string{}
+		}
+		n := [2]byte{b[0] & 0x0F, b[1]}
+		d.mutes.sounding[n] = append(d.mutes.sounding[n], m.part)
+	}
+	d.mutes.mu.Unlock()
+
+	d.sendTrack(m.bytes)
+}
+
+// release forgets a note struck by a part. Note offs sent without the part,
+// e.g. by arrangements, end the note struck first.
+func (m *mutes) release(part string, n [2]byte) {
+	parts := m.sounding[n]
+	i := slices.Index(parts, part)
+	if i < 0 {
+		i = 0
+	}
+	if len(parts) <= 1 {
+		delete(m.sounding, n)
+		return
+	}
+	m.sounding[n] = slices.Delete(parts, i, i+1)
+}
+
+// endMuted sends note offs for sounding notes that stopped being audible
+// since the last tick. Notes also played by audible parts keep sounding.
+func (d *Device) endMuted() {
+	d.mutes.mu.Lock()
+	if !d.mutes.changed {
+		d.mutes.mu.Unlock()
+		return
+	}
+	d.mutes.changed = false
+	var ended [][2]byte
+	for n, parts := range d.mutes.sounding {
+		parts = slices.DeleteFunc(parts, func(part string) bool {
+			return !d.mutes.audible(part, n[0])
+		})
+		if len(parts) == 0 {
+			ended = append(ended, n)
+			delete(d.mutes.sounding, n)
+			continue
+		}
+		d.mutes.sounding[n] = parts
+	}
+	d.mutes.mu.Unlock()
+
+	slices.SortFunc(ended, func(a, b [2]byte) int {
+		return cmp.Or(cmp.Compare(a[0], b[0]), cmp.Compare(a[1], b[1]))
+	})
+	for _, n := range ended {
+		d.sendTrack(midi.NoteOff(n[0], n[1], 0))
+	}
+}
+
+// silenced forgets the sounding notes after they're all ended
+func (m *mutes) silenced() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sounding = nil
+}
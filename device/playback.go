@@ -3,8 +3,6 @@ package device
 import (
 	"context"
 	"fmt"
-	"sync"
-	"sync/atomic"
 	"time"
 
 	"github.com/odaacabeef/beefdown/midi"
@@ -32,16 +30,22 @@ func (d *Device) StartPlaybackListeners() {
 	go func() {
 		for {
 			<-stopSub
-			if d.state.playing() {
+			if !d.state.stopped() {
 				d.CancelF()
 			}
 		}
 	}()
 }
 
-func (d *Device) SetPlaybackConfig(bpm float64, loop bool, sync string) {
+func (d *Device) SetPlaybackConfig(bpm float64, loop, chase, smooth bool, launch, sync string, syncTimeout time.Duration) {
+	d.bpmMu.Lock()
 	d.bpm = bpm
+	d.bpmMu.Unlock()
 	d.loop = loop
+	d.chase = chase
+	d.smooth = smooth
+	d.launch = launch
+	d.syncTimeout = syncTimeout
 	d.updateSync(sync)
 }
 
@@ -71,7 +75,9 @@ func (d *Device) StartPlayback() {
 // playPrimary is intended for top-level arrangements
 func (d *Device) playPrimary(a *sequence.Arrangement) {
 
+	d.bpmMu.Lock()
 	d.beat = time.Duration(float64(time.Minute) / d.bpm)
+	d.bpmMu.Unlock()
 
 	defer func() {
 		// Recover from any panics to ensure cleanup always happens
@@ -79,15 +85,24 @@ func (d *Device) playPrimary(a *sequence.Arrangement) {
 			d.errorsCh <- fmt.Errorf("panic in playback: %v", r)
 		}
 
-		// Stop the clock first
+		// Stop the layers, then the clock
+		d.CancelF()
+		d.clearLayers()
+		d.clockMu.Lock()
 		if d.clock != nil {
 			if err := d.clock.Stop(); err != nil {
 				d.errorsCh <- fmt.Errorf("failed to stop clock: %w", err)
 			}
+			d.clock = nil
+			d.tick = nil
 		}
+		d.state.stop()
+		d.clockMu.Unlock()
 
 		// Update state
-		d.state.stop()
+		d.seek.Store(-1)
+		d.lost.Store(false)
+		d.Queue(nil)
 		d.StopSub.Pub()
 
 		// Send MIDI stop if in leader mode
@@ -95,161 +110,284 @@ func (d *Device) playPrimary(a *sequence.Arrangement) {
 			d.sendSync(midi.Stop())
 		}
 
-		// Always silence all channels to prevent stuck notes
-		for _, m := range midi.SilenceChannel(-1) {
-			d.sendTrack(m)
-		}
+		// Always end sounding notes to prevent stuck notes
+		d.endNotes()
 	}()
 
+	d.transport.Store(0)
 	d.state.play()
 
 	// Handle different sync modes
 	switch d.sync {
 	case "leader":
 		// Leader mode: use Rust clock and send sync messages
-		clock, err := NewClock(d.bpm)
-		if err != nil {
-			d.errorsCh <- fmt.Errorf("failed to create clock: %w", err)
+		if err := d.startClock(); err != nil {
+			d.errorsCh <- err
 			return
 		}
-		d.clock = clock
-
-		err = d.clock.Start(func() {
-			d.ClockSub.Pub()
-			d.sendSync(midi.TimingClock())
-		})
-		if err != nil {
-			d.errorsCh <- fmt.Errorf("failed to start clock: %w", err)
-			return
-		}
-
 		d.sendSync(midi.Start())
 	case "follower":
 		// Follower mode: MIDI listener is already started during initialization
-		// No additional setup needed here
+		// With smoothing, ticks come from a Rust clock locked to the leader
+		if d.smooth {
+			d.received.Store(0)
+			d.generated.Store(0)
+			if err := d.startClock(); err != nil {
+				d.errorsCh <- err
+				return
+			}
+		}
 	default:
 		// No sync mode: use Rust clock only
-		clock, err := NewClock(d.bpm)
-		if err != nil {
-			d.errorsCh <- fmt.Errorf("failed to create clock: %w", err)
+		if err := d.startClock(); err != nil {
+			d.errorsCh <- err
 			return
 		}
-		d.clock = clock
+	}
 
-		err = d.clock.Start(func() {
-			d.ClockSub.Pub()
-		})
-		if err != nil {
-			d.errorsCh <- fmt.Errorf("failed to start clock: %w", err)
+	a.ResetPasses()
+	d.play(a)
+	d.waitLayers()
+}
+
+// startClock creates and starts the Rust clock. Leaders send a timing clock
+// to followers on each tick. Followers start at the leader's tempo if it's
+// known. If playback was paused before the clock started, it's started when
+// playback continues.
+func (d *Device) startClock() error {
+	bpm := d.BPM()
+	if lbpm := d.tracker.BPM(); d.sync == "follower" && lbpm > 0 {
+		bpm = lbpm
+	}
+	clock, err := NewClock(bpm)
+	if err != nil {
+		return fmt.Errorf("failed to create clock: %w", err)
+	}
+
+	d.clockMu.Lock()
+	defer d.clockMu.Unlock()
+	d.clock = clock
+	d.tick = func() {
+		if d.sync == "follower" && !d.lockedTick() {
 			return
 		}
+		d.pubClock()
+		if d.sync == "leader" {
+			d.sendSync(midi.TimingClock())
+		}
+	}
+	if !d.state.playing() {
+		return nil
+	}
+	if err := clock.Start(d.tick); err != nil {
+		return fmt.Errorf("failed to start clock: %w", err)
 	}
+	return nil
+}
 
-	done := make(chan struct{})
-	go d.playRecursive(a, &done)
+// pubClock publishes a clock tick to playback and the layers
+func (d *Device) pubClock() {
+	d.transport.Add(1)
+	d.ClockSub.Pub()
+}
 
-	for {
-		// The Rust clock calls ClockSub.Pub() directly via callback
-		// We just wait for context cancellation or done signal
-		select {
-		case <-d.ctx.Done():
-			return
-		case <-done:
-			return
+// Pause pauses playback, keeping its position
+func (d *Device) Pause() {
+	d.clockMu.Lock()
+	defer d.clockMu.Unlock()
+	if !d.state.swap(playing, paused) {
+		return
+	}
+
+	if d.clock != nil {
+		if err := d.clock.Stop(); err != nil {
+			d.errorsCh <- fmt.Errorf("failed to stop clock: %w", err)
 		}
 	}
+	if d.sync == "leader" {
+		d.sendSync(midi.Stop())
+	}
+	d.endNotes()
 }
 
-// playRecursive can be called for a top-level (primary) arrangement or
-// recursively for arrangements nested within arrangements.
-func (d *Device) playRecursive(a *sequence.Arrangement, done *chan struct{}) {
-	var clockIdx int64
+// Continue resumes paused playback. Leaders resume from the next sixteenth
+// note and send its song position to followers before continuing.
+func (d *Device) Continue() {
+	d.clockMu.Lock()
+	defer d.clockMu.Unlock()
+	if !d.state.paused() {
+		return
+	}
 
-	clockSub := make(chan struct{})
-	d.ClockSub.Sub(a.Name(), clockSub)
+	if d.sync == "leader" {
+		position := min((d.position.Load()+5)/6, 0x3FFF)
+		d.seek.Store(position * 6)
+		d.sendSync(midi.SongPositionPointer(uint16(position)))
+		d.sendSync(midi.Continue())
+	}
+	if !d.state.swap(paused, playing) {
+		return
+	}
 
-	defer d.ClockSub.Unsub(a.Name())
+	if d.clock != nil {
+		d.received.Store(0)
+		d.generated.Store(0)
+		if err := d.clock.Start(d.tick); err != nil {
+			d.errorsCh <- fmt.Errorf("failed to start clock: %w", err)
+		}
+	}
+}
+
+// play walks a timeline of each pass of the arrangement, one clock tick at a
+// time. Queued playables launch in its place without stopping the clock.
+func (d *Device) play(a *sequence.Arrangement) {
+	// Buffered so ticks aren't dropped while messages are being sent
+	clockSub := make(chan struct{}, 96)
+	d.ClockSub.Sub("playback", clockSub)
+	defer d.ClockSub.Unsub("playback")
+
+	d.position.Store(0)
+	// Positions of the start of the arrangement's first pass and current pass
+	var origin, passStart int
+	// Whether the clock tick for the next tick has already been received
+	var received bool
+passes:
+	for pass := 0; ; pass++ {
+		// Generated parts with regen may change at the start of each pass
+		if err := a.Regenerate(); err != nil {
+			d.errorsCh <- err
+		}
+		timeline := a.Compile()
+		if timeline.Ticks == 0 {
+			return
+		}
 
-	if done != nil {
-		defer close(*done)
+		// Start from the cued step unless a follower was sent a position
+		if c := a.Cue(); pass == 0 && c != nil && d.seek.Load() < 0 {
+			if tick, ok := timeline.StepTick(a, *c); ok {
+				d.seek.Store(int64(passStart + tick))
+			}
+		}
+
+		// Messages decided by triggers, by tick
+		pending := map[int][]message{}
+		events := timeline.Events
+		for tick := 0; tick < timeline.Ticks; tick++ {
+			if !received && !d.waitTick(d.ctx, clockSub) {
+				return
+			}
+			received = false
+
+			boundary := d.boundary(a, events, passStart+tick, tick)
+			if next := d.launchDue(boundary); next != nil {
+				d.switchTo(a, next)
+				a = next
+				origin, passStart = passStart+tick, passStart+tick
+				received = true
+				pass = -1
+				continue passes
+			}
+
+			if target := int(d.seek.Swap(-1)); target >= 0 {
+				if target-origin >= timeline.Ticks && !d.loop {
+					return
+				}
+				// Seeking back starts the parts' passes over
+				if target < passStart+tick {
+					a.ResetPasses()
+				}
+				d.endPrimary()
+				tick = ((target-origin)%timeline.Ticks + timeline.Ticks) % timeline.Ticks
+				passStart = target - tick
+				events, pending = d.cue(timeline, tick)
+			}
+
+			d.endMuted()
+			for len(events) > 0 && events[0].Tick == tick {
+				d.handleEvent(events[0], pending, d.sendPrimary)
+				events = events[1:]
+			}
+			for _, m := range pending[tick] {
+				d.sendPrimary(m)
+			}
+			delete(pending, tick)
+			d.position.Store(int64(passStart + tick + 1))
+		}
+		passStart += timeline.Ticks
+
+		if !d.loop {
+			return
+		}
 	}
+}
 
+// waitTick waits for the clock tick of the next tick to play. Ticks buffered
+// before pausing are dropped. It returns false if ctx is cancelled.
+func (d *Device) waitTick(ctx context.Context, clockSub chan struct{}) bool {
 	for {
-		for aidx, stepPlayables := range a.Playables {
-			select {
-			case <-d.ctx.Done():
-				return
-			default:
-				a.UpdateStep(aidx)
+		select {
+		case <-ctx.Done():
+			return false
+		case <-clockSub:
+		}
+		if d.state.playing() {
+			return true
+		}
+	}
+}
+
+// cue prepares to play a timeline from a tick. Playables are moved to the
+// steps they're on, and parts are triggered for steps starting from the tick
+// whose notes start before it. With chase, notes that would still be sounding
+// are played again on the tick. It returns the events from the tick on.
+func (d *Device) cue(t *sequence.Timeline, tick int) ([]sequence.Event, map[int][]message) {
+	pending := map[int][]message{}
+	if d.chase {
+		for _, e := range t.Sounding(tick) {
+			for _, m := range e.Messages {
+				pending[tick] = append(pending[tick], message{e.Playable.Name(), m})
 			}
-			select {
-			case <-d.ctx.Done():
-				return
-			default:
-				var wg sync.WaitGroup
-				var tick []chan struct{}
-				stepDone := make(chan struct{})
-				var stepParts []*sequence.Part
-				for _, p := range stepPlayables {
-					wg.Add(1)
-					switch p := p.(type) {
-					case *sequence.Part:
-						tick = append(tick, make(chan struct{}))
-						stepParts = append(stepParts, p)
-						go func(part *sequence.Part, t chan struct{}) {
-							defer wg.Done()
-							for sidx, sm := range part.StepMIDI {
-								select {
-								case <-d.ctx.Done():
-									return
-								case <-t:
-									part.UpdateStep(sidx)
-									for _, m := range sm.Off {
-										d.sendTrack(m)
-									}
-									for _, m := range sm.On {
-										d.sendTrack(m)
-									}
-								}
-							}
-						}(p, tick[len(tick)-1])
-					case *sequence.Arrangement:
-						go func() {
-							defer wg.Done()
-							d.playRecursive(p, nil)
-						}()
-					}
+		}
+	}
+	events := t.Events
+	for len(events) > 0 && events[0].Tick < tick {
+		switch e := events[0]; {
+		case e.Type == sequence.StepEvent:
+			d.handleEvent(e, pending, d.sendPrimary)
+		case e.Type == sequence.TriggerEvent && e.Start >= tick:
+			d.handleEvent(e, pending, d.sendPrimary)
+		}
+		events = events[1:]
+	}
+	return events, pending
+}
+
+// handleEvent handles a timeline event on its tick. Messages sent right away
+// go through send.
+func (d *Device) handleEvent(e sequence.Event, pending map[int][]message, send func(message)) {
+	switch e.Type {
+	case sequence.StepEvent:
+		e.Playable.UpdateStep(e.Step)
+	case sequence.TriggerEvent:
+		part := e.Playable.Name()
+		on, sub := e.Playable.(*sequence.Part).Trigger(e.Step, d.Fill())
+		for _, m := range on {
+			pending[e.Start] = append(pending[e.Start], message{part, m})
+		}
+		for k, msgs := range sub {
+			if tick := e.Start + k; tick < e.End {
+				for _, m := range msgs {
+					pending[tick] = append(pending[tick], message{part, m})
 				}
-				go func() {
-					stepCounts := make([]int64, len(stepParts))
-					for {
-						select {
-						case <-d.ctx.Done():
-							return
-						case <-stepDone:
-							return
-						case <-clockSub:
-							for i, t := range tick {
-								currentIdx := atomic.LoadInt64(&clockIdx)
-								if currentIdx%int64(stepParts[i].Div()) == 0 && atomic.LoadInt64(&stepCounts[i]) < int64(len(stepParts[i].StepMIDI)) {
-									select {
-									case t <- struct{}{}:
-										atomic.AddInt64(&stepCounts[i], 1)
-									default:
-										// Channel is full or closed, skip
-									}
-								}
-							}
-							atomic.AddInt64(&clockIdx, 1)
-						}
-					}
-				}()
-				wg.Wait()
-				close(stepDone)
 			}
 		}
-		if !d.loop || done == nil {
-			break
+	case sequence.MessageEvent:
+		var part string
+		if e.Playable != nil {
+			part = e.Playable.Name()
+		}
+		for _, m := range e.Messages {
+			send(message{part, m})
 		}
 	}
 }
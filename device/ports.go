@@ -2,6 +2,7 @@ package device
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/odaacabeef/beefdown/midi"
 )
@@ -22,6 +23,58 @@ func (d *Device) sendTrack(bytes []byte) {
 	}
 }
 
+// endNotes sends note offs for the notes sounding on the track output, and
+// lifts the sustain pedal where it's down
+func (d *Device) endNotes() {
+	if d.trackOut == nil {
+		return
+	}
+	for _, m := range d.trackOut.notes.Off() {
+		d.sendTrack(m)
+	}
+	d.mutes.silenced()
+	d.primary.Off()
+}
+
+// sendPrimary sends a message from the playable playing, keeping track of the
+// notes it has sounding
+func (d *Device) sendPrimary(m message) {
+	d.primary.Track(m.bytes)
+	d.send(m)
+}
+
+// endPrimary sends note offs for the notes the playable playing has sounding,
+// and lifts the sustain pedal where it put it down. Layers keep their notes,
+// including notes they share with it.
+func (d *Device) endPrimary() {
+	d.layersMu.Lock()
+	layers := slices.Clone(d.layers)
+	d.layersMu.Unlock()
+
+	for _, b := range d.primary.Off() {
+		layered := slices.ContainsFunc(layers, func(l *layer) bool {
+			return l.has(b[0]&0x0F, b[1])
+		})
+		if midi.IsNoteOff(b) && layered {
+			continue
+		}
+		d.send(message{bytes: b})
+	}
+}
+
+// Panic fully resets every channel of the track output, for notes stuck on
+// synths whatever sent them
+func (d *Device) Panic() {
+	for _, m := range midi.Panic() {
+		d.sendTrack(m)
+	}
+	if d.trackOut != nil {
+		d.trackOut.notes.Off()
+	}
+	d.mutes.silenced()
+	d.primary.Off()
+}
+
 func (d *Device) sendSync(bytes []byte) {
 	if d.syncOut == nil {
 		// No sync output configured, ignore the message
@@ -53,6 +106,7 @@ func (d *Device) updateSync(mode string) {
 			if d.syncIn != nil {
 				d.syncIn.StopListening()
 			}
+			d.unwatchSync()
 			d.listening = false
 		}
 		return
@@ -74,20 +128,41 @@ func (d *Device) updateSync(mode string) {
 	err := d.syncIn.Listen(func(bytes []byte, timestamp int64) {
 		switch {
 		case midi.IsStart(bytes):
-			// Start message received - trigger clock events
-			if d.state.stopped() {
+			// Start message received - play from the beginning
+			d.lost.Store(false)
+			d.watchSync()
+			switch {
+			case d.state.stopped():
+				d.seek.Store(-1)
 				d.PlaySub.Pub()
+			case d.state.paused():
+				d.seek.Store(0)
+				d.Continue()
 			}
 		case midi.IsStop(bytes):
-			// Stop message received - stop playback
-			if d.state.playing() {
-				d.StopSub.Pub()
+			// Stop message received - pause playback, keeping its position
+			d.lost.Store(false)
+			d.Pause()
+		case midi.IsContinue(bytes):
+			// Continue message received - resume from the current position,
+			// or from the last song position if stopped
+			d.lost.Store(false)
+			d.watchSync()
+			switch {
+			case d.state.stopped():
+				d.PlaySub.Pub()
+			case d.state.paused():
+				d.Continue()
 			}
+		case midi.IsSongPositionPointer(bytes):
+			// Song position received - move there on the next timing clock
+			d.seek.Store(int64(midi.SongPosition(bytes) * 6))
 		case midi.IsTimingClock(bytes):
-			// Timing clock message received - trigger clock events
-			if d.state.playing() {
-				d.ClockSub.Pub()
-			}
+			// Timing clock message received - trigger clock events,
+			// continuing if sync was lost
+			d.watchSync()
+			d.syncFound()
+			d.leaderClock(timestamp)
 		}
 	})
 
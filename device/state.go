@@ -7,6 +7,7 @@ type state uint32
 const (
 	playing state = 1
 	stopped state = 0
+	paused  state = 2
 )
 
 func newState() state {
@@ -21,6 +22,15 @@ func (s *state) stop() {
 	atomic.StoreUint32((*uint32)(s), uint32(stopped))
 }
 
+func (s *state) pause() {
+	atomic.StoreUint32((*uint32)(s), uint32(paused))
+}
+
+// swap changes the state from old to new, and reports whether it did
+func (s *state) swap(old, new state) bool {
+	return atomic.CompareAndSwapUint32((*uint32)(s), uint32(old), uint32(new))
+}
+
 func (s *state) playing() bool {
 	return atomic.LoadUint32((*uint32)(s)) == uint32(playing)
 }
@@ -29,17 +39,26 @@ func (s *state) stopped() bool {
 	return atomic.LoadUint32((*uint32)(s)) == uint32(stopped)
 }
 
+func (s *state) paused() bool {
+	return atomic.LoadUint32((*uint32)(s)) == uint32(paused)
+}
+
 func (s *state) string() string {
 	switch atomic.LoadUint32((*uint32)(s)) {
 	case uint32(playing):
 		return "playing"
 	case uint32(stopped):
 		return "stopped"
+	case uint32(paused):
+		return "paused"
 	}
 	return ""
 }
 
 func (d *Device) State() string {
+	if d.lost.Load() && d.state.paused() {
+		return "sync lost"
+	}
 	return d.state.string()
 }
 
@@ -50,3 +69,7 @@ func (d *Device) Playing() bool {
 func (d *Device) Stopped() bool {
 	return d.state.stopped()
 }
+
+func (d *Device) Paused() bool {
+	return d.state.paused()
+}
@@ -0,0 +1,54 @@
+package device
+
+import (
+	"fmt"
+	"time"
+)
+
+// watchSync restarts the sync timeout of a follower. It's called when the
+// leader starts or continues and on each timing clock.
+func (d *Device) watchSync() {
+	if d.syncTimeout <= 0 {
+		return
+	}
+	d.syncMu.Lock()
+	defer d.syncMu.Unlock()
+	if d.syncTimer == nil {
+		d.syncTimer = time.AfterFunc(d.syncTimeout, d.syncLost)
+		return
+	}
+	d.syncTimer.Reset(d.syncTimeout)
+}
+
+// unwatchSync stops the sync timeout when the device stops following
+func (d *Device) unwatchSync() {
+	d.syncMu.Lock()
+	defer d.syncMu.Unlock()
+	if d.syncTimer != nil {
+		d.syncTimer.Stop()
+		d.syncTimer = nil
+	}
+}
+
+// syncLost pauses a follower that's playing when the leader's timing clock
+// stops arriving, ending its notes, and reports it. Playback continues when
+// the clock comes back.
+func (d *Device) syncLost() {
+	if !d.state.playing() {
+		return
+	}
+	d.lost.Store(true)
+	d.Pause()
+
+	select {
+	case d.errorsCh <- fmt.Errorf("sync lost: no timing clock for %s", d.syncTimeout):
+	default:
+	}
+}
+
+// syncFound continues playback paused by losing sync
+func (d *Device) syncFound() {
+	if d.lost.Swap(false) && d.state.paused() {
+		d.Continue()
+	}
+}
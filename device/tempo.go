@@ -0,0 +1,133 @@
+package device
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	minBPM = 20
+	maxBPM = 400
+
+	// Taps further apart than this start a new tap tempo
+	tapTimeout = 2 * time.Second
+	// Tap tempo averages this many of the latest taps
+	tapCount = 5
+
+	// A smoothed follower clock plays the leader's clocks right away once it's
+	// this many behind
+	maxLag = 3
+)
+
+// BPM returns the tempo, including changes made while playing
+func (d *Device) BPM() float64 {
+	d.bpmMu.RLock()
+	defer d.bpmMu.RUnlock()
+	return d.bpm
+}
+
+// SetBPM changes the tempo, taking effect on the running clock right away so
+// followers get the new tempo too. Followers take their tempo from the
+// leader, so it's only stored.
+func (d *Device) SetBPM(bpm float64) error {
+	bpm = min(max(bpm, minBPM), maxBPM)
+
+	d.bpmMu.Lock()
+	d.bpm = bpm
+	d.beat = time.Duration(float64(time.Minute) / bpm)
+	d.bpmMu.Unlock()
+
+	if d.sync == "follower" || d.state.stopped() {
+		return nil
+	}
+	if err := d.setClockBPM(bpm); err != nil {
+		return fmt.Errorf("failed to set BPM: %w", err)
+	}
+	return nil
+}
+
+// NudgeBPM changes the tempo by delta beats per minute
+func (d *Device) NudgeBPM(delta float64) error {
+	return d.SetBPM(d.BPM() + delta)
+}
+
+// Tap taps tap tempo. Once there are two taps close enough together, the
+// tempo is set to the average time between the latest taps.
+func (d *Device) Tap(now time.Time) error {
+	d.bpmMu.Lock()
+	if len(d.taps) > 0 && now.Sub(d.taps[len(d.taps)-1]) > tapTimeout {
+		d.taps = nil
+	}
+	d.taps = append(d.taps, now)
+	if len(d.taps) > tapCount {
+		d.taps = d.taps[len(d.taps)-tapCount:]
+	}
+	taps := d.taps
+	d.bpmMu.Unlock()
+
+	if len(taps) < 2 {
+		return nil
+	}
+	interval := taps[len(taps)-1].Sub(taps[0]) / time.Duration(len(taps)-1)
+	return d.SetBPM(float64(time.Minute) / float64(interval))
+}
+
+// LeaderBPM returns the tempo of the leader's timing clock, estimated from
+// when its clocks arrive, or 0 if it isn't known yet
+func (d *Device) LeaderBPM() float64 {
+	return d.tracker.BPM()
+}
+
+// leaderClock handles a timing clock from the leader, arriving at a time in
+// microseconds. Without smoothing, it's played right away. With smoothing, the
+// Rust clock plays ticks, and its tempo follows the leader's estimated tempo,
+// sped up or slowed down to keep up with the leader's clocks.
+func (d *Device) leaderClock(us int64) {
+	d.tracker.Clock(us)
+	if !d.state.playing() {
+		return
+	}
+	if !d.smooth || !d.clocked() {
+		d.pubClock()
+		return
+	}
+
+	lag := d.received.Add(1) - d.generated.Load()
+	for ; lag > maxLag; lag-- {
+		d.generated.Add(1)
+		d.pubClock()
+	}
+	if bpm := d.tracker.BPM(); bpm > 0 {
+		if err := d.setClockBPM(bpm * (1 + 0.02*float64(lag))); err != nil {
+			d.errorsCh <- fmt.Errorf("failed to set BPM: %w", err)
+		}
+	}
+}
+
+// clocked reports whether ticks come from the Rust clock
+func (d *Device) clocked() bool {
+	d.clockMu.Lock()
+	defer d.clockMu.Unlock()
+	return d.clock != nil
+}
+
+// setClockBPM sets the tempo of the Rust clock, if there is one
+func (d *Device) setClockBPM(bpm float64) error {
+	d.clockMu.Lock()
+	defer d.clockMu.Unlock()
+	if d.clock == nil {
+		return nil
+	}
+	return d.clock.SetBPM(bpm)
+}
+
+// lockedTick reports whether a tick of a smoothed follower's clock should be
+// played. It can get at most a tick ahead of the leader's clocks, so it
+// doesn't run on when the leader stops.
+func (d *Device) lockedTick() bool {
+	if d.generated.Load() > d.received.Load() {
+		return false
+	}
+	d.generated.Add(1)
+	return true
+}
@@ -0,0 +1,43 @@
+#!/usr/bin/env python3
+"""Plays a part's notes as 16ths with accents and a filter sweep, returning
+typed events."""
+import json
+import sys
+
+NAMES = {"c": 0, "d": 2, "e": 4, "f": 5, "g": 7, "a": 9, "b": 11}
+
+
+def midi(note):
+    name = note.split(":")[0]
+    pitch = NAMES[name[0]]
+    rest = name[1:]
+    if rest.startswith("#"):
+        pitch, rest = pitch + 1, rest[1:]
+    elif rest.startswith("b"):
+        pitch, rest = pitch - 1, rest[1:]
+    return (int(rest) + 1) * 12 + pitch
+
+
+data = json.load(sys.stdin)
+div = data["div"]
+source = [s for s in data["parts"][data["params"]["parts"]] if s]
+length = int(data["params"].get("length", 16))
+
+events = []
+for i in range(length):
+    tick = i * div
+    events.append({
+        "type": "note",
+        "tick": tick,
+        "note": midi(source[i % len(source)]),
+        "velocity": 120 if i % 4 == 0 else 70,
+        "length": div // 2,
+    })
+    events.append({
+        "type": "cc",
+        "tick": tick,
+        "controller": 74,
+        "value": int(127 * i / max(length - 1, 1)),
+    })
+
+json.dump({"length": length, "events": events}, sys.stdout)
@@ -0,0 +1,14 @@
+#!/usr/bin/env python3
+"""Arpeggiates the notes param up and down, returning step text."""
+import json
+import sys
+
+data = json.load(sys.stdin)
+params = data["params"]
+notes = params["notes"].split(",")
+length = int(params.get("length", 16))
+
+cycle = notes + notes[-2:0:-1]
+steps = [f"{cycle[i % len(cycle)]}:1" for i in range(length)]
+
+json.dump({"steps": steps}, sys.stdout)
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"text/tabwriter"
 
+	"github.com/odaacabeef/beefdown/sequence/generators"
 	"github.com/odaacabeef/beefdown/ui"
 
 	"net/http"
@@ -20,9 +22,15 @@ func main() {
 	args := flag.Args()
 	if len(args) != 1 {
 		fmt.Println("Usage: beefdown <sequence-file>")
+		fmt.Println("       beefdown generators")
 		os.Exit(1)
 	}
 
+	if args[0] == "generators" {
+		printGenerators()
+		return
+	}
+
 	_, pprof := os.LookupEnv("BEEF_PPROF")
 	if pprof {
 		addr := os.Getenv("BEEF_PPROF_ADDR")
@@ -39,3 +47,25 @@ func main() {
 		log.Fatal("Failed to start UI: ", err)
 	}
 }
+
+// printGenerators lists every generator type and its parameters
+func printGenerators() {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for i, spec := range generators.Specs() {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, ".gen.%s: %s\n", spec.Name, spec.Description)
+		for _, p := range spec.Params {
+			def := p.Default
+			if p.Required {
+				def = "required"
+			}
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n", p.Name, p.Type, def, p.Range(), p.Description)
+		}
+		if spec.AnyParams {
+			fmt.Fprintf(w, "  ...\t\t\t\tOther parameters are passed through\n")
+		}
+	}
+	w.Flush()
+}
@@ -42,6 +42,12 @@ func TimingClock() []byte {
 	return []byte{0xF8}
 }
 
+// SongPositionPointer creates a MIDI Song Position Pointer message. The
+// position is in sixteenth notes (6 timing clocks) from the start of the song.
+func SongPositionPointer(position uint16) []byte {
+	return []byte{0xF2, uint8(position & 0x7F), uint8((position >> 7) & 0x7F)}
+}
+
 // SilenceChannel creates MIDI messages to silence a channel
 // Uses CC 123 (All Notes Off)
 // If channel is -1, silences all 16 channels
@@ -59,6 +65,24 @@ func SilenceChannel(channel int) [][]byte {
 	return [][]byte{ControlChange(uint8(channel&0x0F), 123, 0)}
 }
 
+// Panic creates MIDI messages to fully reset every channel: note offs for
+// every note, then All Sound Off (CC 120), Reset All Controllers (CC 121) and
+// All Notes Off (CC 123)
+func Panic() [][]byte {
+	var messages [][]byte
+	for ch := uint8(0); ch < 16; ch++ {
+		for note := uint8(0); note < 128; note++ {
+			messages = append(messages, NoteOff(ch, note, 0))
+		}
+		messages = append(messages,
+			ControlChange(ch, 120, 0),
+			ControlChange(ch, 121, 0),
+			ControlChange(ch, 123, 0),
+		)
+	}
+	return messages
+}
+
 // MIDI message checkers
 
 // IsStart checks if a message is a MIDI Start message
@@ -81,6 +105,17 @@ func IsTimingClock(bytes []byte) bool {
 	return len(bytes) == 1 && bytes[0] == 0xF8
 }
 
+// IsSongPositionPointer checks if a message is a MIDI Song Position Pointer message
+func IsSongPositionPointer(bytes []byte) bool {
+	return len(bytes) == 3 && bytes[0] == 0xF2
+}
+
+// SongPosition returns the position of a Song Position Pointer message in
+// sixteenth notes
+func SongPosition(bytes []byte) int {
+	return int(bytes[1]&0x7F) | int(bytes[2]&0x7F)<<7
+}
+
 // IsNoteOn checks if a message is a Note On message
 func IsNoteOn(bytes []byte) bool {
 	return len(bytes) == 3 && (bytes[0]&0xF0) == 0x90 && bytes[2] != 0
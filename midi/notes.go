@@ -0,0 +1,54 @@
+package midi
+
+import "sync"
+
+// Notes keeps track of the notes sounding on each channel, and whether the
+// sustain pedal is down, from the messages sent to an output. Notes are
+// counted, so a note struck twice is still sounding after one note off.
+type Notes struct {
+	mu    sync.Mutex
+	on    [16][128]int
+	pedal [16]bool
+}
+
+// sustainPedal is the sustain pedal controller (CC64)
+const sustainPedal = 64
+
+// Track records a message sent to the output
+func (n *Notes) Track(bytes []byte) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	switch {
+	case IsNoteOff(bytes):
+		if on := &n.on[bytes[0]&0x0F][bytes[1]&0x7F]; *on > 0 {
+			*on--
+		}
+	case IsNoteOn(bytes):
+		n.on[bytes[0]&0x0F][bytes[1]&0x7F]++
+	case IsControlChange(bytes) && bytes[1] == sustainPedal:
+		n.pedal[bytes[0]&0x0F] = bytes[2] >= 64
+	}
+}
+
+// Off returns note offs for the notes sounding and lifts the sustain pedal
+// where it's down, by channel, and forgets them
+func (n *Notes) Off() [][]byte {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	var msgs [][]byte
+	for ch := range n.on {
+		for note, on := range n.on[ch] {
+			if on > 0 {
+				msgs = append(msgs, NoteOff(uint8(ch), uint8(note), 0))
+			}
+		}
+		if n.pedal[ch] {
+			msgs = append(msgs, ControlChange(uint8(ch), sustainPedal, 0))
+		}
+	}
+	n.on = [16][128]int{}
+	n.pedal = [16]bool{}
+	return msgs
+}
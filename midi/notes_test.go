@@ -0,0 +1,73 @@
+package midi
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNotes(t *testing.T) {
+	var n Notes
+	for _, m := range [][]byte{
+		NoteOn(0, 60, 100),
+		NoteOn(0, 64, 100),
+		NoteOn(9, 36, 100),
+		NoteOff(0, 60, 0),
+		{0x90, 36, 0}, // channel 1 note on with no velocity doesn't end channel 10's note
+		ControlChange(1, 64, 127),
+		NoteOn(1, 48, 90),
+		{0x91, 48, 0}, // note on with no velocity is a note off
+		ControlChange(2, 64, 127),
+		ControlChange(2, 64, 0),
+	} {
+		n.Track(m)
+	}
+
+	want := [][]byte{
+		NoteOff(0, 64, 0),
+		ControlChange(1, 64, 0),
+		NoteOff(9, 36, 0),
+	}
+	got := n.Off()
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("Off() = %v, want %v", got, want)
+	}
+	if got := n.Off(); len(got) != 0 {
+		t.Errorf("Off() again = %v, want none", got)
+	}
+}
+
+func TestPanic(t *testing.T) {
+	msgs := Panic()
+	if len(msgs) != 16*(128+3) {
+		t.Fatalf("len(Panic()) = %d, want %d", len(msgs), 16*(128+3))
+	}
+	last := msgs[len(msgs)-3:]
+	want := [][]byte{{0xBF, 120, 0}, {0xBF, 121, 0}, {0xBF, 123, 0}}
+	if !slices.EqualFunc(last, want, slices.Equal) {
+		t.Errorf("Panic() ends with %v, want %v", last, want)
+	}
+}
+
+func TestNotesCounted(t *testing.T) {
+	var n Notes
+	for _, m := range [][]byte{
+		NoteOn(0, 60, 100),
+		NoteOn(0, 60, 100),
+		NoteOff(0, 60, 0),
+		NoteOff(3, 62, 0), // note off for a note that isn't sounding
+	} {
+		n.Track(m)
+	}
+
+	// The second note on is still sounding, and ends with one note off
+	want := [][]byte{NoteOff(0, 60, 0)}
+	if got := n.Off(); !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("Off() = %v, want %v", got, want)
+	}
+
+	n.Track(NoteOn(3, 62, 100))
+	want = [][]byte{NoteOff(3, 62, 0)}
+	if got := n.Off(); !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("Off() after a stray note off = %v, want %v", got, want)
+	}
+}
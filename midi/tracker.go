@@ -0,0 +1,69 @@
+package midi
+
+import "sync"
+
+// Tracker estimates the tempo of incoming timing clock messages from the
+// times they arrive. It's a phase-locked loop: each clock is compared to when
+// it was expected, and a fraction of the error corrects the expected time of
+// the next clock and the clock period, so jitter is smoothed out.
+type Tracker struct {
+	mu       sync.Mutex
+	ticks    int     // Clocks since the tracker last locked on
+	last     int64   // Time of the last clock, in microseconds
+	expected float64 // Expected time of the next clock
+	period   float64 // Estimated time between clocks
+}
+
+const (
+	// Fractions of the error correcting the phase and period
+	trackerPhaseGain  = 0.2
+	trackerPeriodGain = 0.02
+
+	// Clocks needed before the estimate is reported
+	trackerLock = 24
+)
+
+// Clock records a timing clock arriving at a time in microseconds. A clock
+// far from when it was expected, like the first after the leader stopped,
+// starts the estimate over.
+func (t *Tracker) Clock(us int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch {
+	case t.ticks == 0:
+	case t.ticks == 1 || t.period <= 0:
+		t.period = float64(us - t.last)
+		t.expected = float64(us) + t.period
+	default:
+		err := float64(us) - t.expected
+		if err > 4*t.period || err < -t.period {
+			t.ticks = 0
+			break
+		}
+		t.period += trackerPeriodGain * err
+		t.expected += trackerPhaseGain*err + t.period
+	}
+	t.ticks++
+	t.last = us
+}
+
+// BPM returns the estimated tempo, or 0 until enough clocks have arrived to
+// lock on to it
+func (t *Tracker) BPM() float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.ticks < trackerLock || t.period <= 0 {
+		return 0
+	}
+	// 24 clocks per beat
+	return 60e6 / (t.period * 24)
+}
+
+// Reset forgets the estimate
+func (t *Tracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.ticks = 0
+	t.period = 0
+}
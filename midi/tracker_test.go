@@ -0,0 +1,45 @@
+package midi
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestTracker(t *testing.T) {
+	// 120 BPM is 24 clocks every 500ms
+	const period = 500000.0 / 24
+
+	var tr Tracker
+	rng := rand.New(rand.NewSource(1))
+	for i := range 23 {
+		tr.Clock(int64(float64(i) * period))
+	}
+	if bpm := tr.BPM(); bpm != 0 {
+		t.Errorf("BPM() before locking = %g, want 0", bpm)
+	}
+
+	// Up to 2ms of jitter either way
+	for i := 23; i < 24*16; i++ {
+		jitter := (rng.Float64()*2 - 1) * 2000
+		tr.Clock(int64(float64(i)*period + jitter))
+	}
+	if bpm := tr.BPM(); math.Abs(bpm-120) > 1 {
+		t.Errorf("BPM() with jitter = %g, want about 120", bpm)
+	}
+
+	// The leader speeds up to 150 BPM
+	start := 24 * 16 * period
+	for i := range 24 * 16 {
+		tr.Clock(int64(start + float64(i)*500000.0/30))
+	}
+	if bpm := tr.BPM(); math.Abs(bpm-150) > 0.5 {
+		t.Errorf("BPM() after tempo change = %g, want about 150", bpm)
+	}
+
+	// A gap, like the leader stopping, starts over
+	tr.Clock(int64(start + 24*20*500000.0/30))
+	if bpm := tr.BPM(); bpm != 0 {
+		t.Errorf("BPM() after gap = %g, want 0", bpm)
+	}
+}
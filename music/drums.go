@@ -0,0 +1,74 @@
+package music
+
+import "maps"
+
+// DrumMap maps drum names (e.g. "kick", "snare") to MIDI note numbers
+type DrumMap map[string]uint8
+
+// builtinDrumMaps are available to every sequence without being defined
+var builtinDrumMaps = map[string]DrumMap{
+	"gm": {
+		// General MIDI percussion key map (channel 10)
+		"kick2":     35, // Acoustic Bass Drum
+		"kick":      36, // Bass Drum 1
+		"bd":        36,
+		"rim":       37, // Side Stick
+		"rs":        37,
+		"snare":     38, // Acoustic Snare
+		"sd":        38,
+		"clap":      39, // Hand Clap
+		"cp":        39,
+		"snare2":    40, // Electric Snare
+		"ltom2":     41, // Low Floor Tom
+		"chh":       42, // Closed Hi-Hat
+		"ltom1":     43, // High Floor Tom
+		"phh":       44, // Pedal Hi-Hat
+		"ltom":      45, // Low Tom
+		"ohh":       46, // Open Hi-Hat
+		"mtom":      47, // Low-Mid Tom
+		"mtom2":     48, // Hi-Mid Tom
+		"crash":     49, // Crash Cymbal 1
+		"htom":      50, // High Tom
+		"ride":      51, // Ride Cymbal 1
+		"china":     52, // Chinese Cymbal
+		"bell":      53, // Ride Bell
+		"tamb":      54, // Tambourine
+		"splash":    55, // Splash Cymbal
+		"cowbell":   56, // Cowbell
+		"cb":        56,
+		"crash2":    57, // Crash Cymbal 2
+		"vibraslap": 58, // Vibraslap
+		"ride2":     59, // Ride Cymbal 2
+		"hbongo":    60, // Hi Bongo
+		"lbongo":    61, // Low Bongo
+		"mconga":    62, // Mute Hi Conga
+		"hconga":    63, // Open Hi Conga
+		"lconga":    64, // Low Conga
+		"htimbale":  65, // High Timbale
+		"ltimbale":  66, // Low Timbale
+		"hagogo":    67, // High Agogo
+		"lagogo":    68, // Low Agogo
+		"cabasa":    69, // Cabasa
+		"maracas":   70, // Maracas
+		"swhistle":  71, // Short Whistle
+		"lwhistle":  72, // Long Whistle
+		"sguiro":    73, // Short Guiro
+		"lguiro":    74, // Long Guiro
+		"claves":    75, // Claves
+		"hwood":     76, // Hi Wood Block
+		"lwood":     77, // Low Wood Block
+		"mcuica":    78, // Mute Cuica
+		"ocuica":    79, // Open Cuica
+		"mtri":      80, // Mute Triangle
+		"otri":      81, // Open Triangle
+	},
+}
+
+// BuiltinDrumMap returns a copy of the built-in drum map with the given name
+func BuiltinDrumMap(name string) (DrumMap, bool) {
+	dm, ok := builtinDrumMaps[name]
+	if !ok {
+		return nil, false
+	}
+	return maps.Clone(dm), true
+}
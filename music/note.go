@@ -1,6 +1,7 @@
 package music
 
 import (
+	"fmt"
 	"strconv"
 )
 
@@ -39,3 +40,26 @@ func Note(name string, octave string) (*uint8, error) {
 	}
 	return &note, nil
 }
+
+// ParseNote parses a note name with octave (e.g. "c4", "f#2") or a plain MIDI
+// note number (e.g. "36")
+func ParseNote(s string) (uint8, error) {
+	if num, err := strconv.ParseUint(s, 10, 8); err == nil {
+		if num > 127 {
+			return 0, fmt.Errorf("invalid note number: %s", s)
+		}
+		return uint8(num), nil
+	}
+	i := 1
+	if len(s) > 1 && (s[1] == 'b' || s[1] == '#') {
+		i = 2
+	}
+	if len(s) <= i || s[0] < 'a' || s[0] > 'g' {
+		return 0, fmt.Errorf("invalid note: %s", s)
+	}
+	note, err := Note(s[:i], s[i:])
+	if err != nil {
+		return 0, fmt.Errorf("invalid note: %s", s)
+	}
+	return *note, nil
+}
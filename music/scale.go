@@ -0,0 +1,111 @@
+package music
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Scales defined as intervals (in semitones from the tonic)
+var scaleIntervals = map[string][]uint8{
+	// Diatonic modes
+	"major":      {0, 2, 4, 5, 7, 9, 11}, // Ionian
+	"ionian":     {0, 2, 4, 5, 7, 9, 11},
+	"dorian":     {0, 2, 3, 5, 7, 9, 10},
+	"phrygian":   {0, 1, 3, 5, 7, 8, 10},
+	"lydian":     {0, 2, 4, 6, 7, 9, 11},
+	"mixolydian": {0, 2, 4, 5, 7, 9, 10},
+	"minor":      {0, 2, 3, 5, 7, 8, 10}, // Natural minor (Aeolian)
+	"aeolian":    {0, 2, 3, 5, 7, 8, 10},
+	"locrian":    {0, 1, 3, 5, 6, 8, 10},
+
+	// Minor variants
+	"harmonic-minor": {0, 2, 3, 5, 7, 8, 11},
+	"melodic-minor":  {0, 2, 3, 5, 7, 9, 11}, // Ascending form
+
+	// Pentatonic and blues
+	"pentatonic":       {0, 2, 4, 7, 9}, // Major pentatonic
+	"minor-pentatonic": {0, 3, 5, 7, 10},
+	"blues":            {0, 3, 5, 6, 7, 10},
+
+	// Symmetric
+	"whole-tone": {0, 2, 4, 6, 8, 10},
+	"chromatic":  {0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11},
+}
+
+var noteNames = []string{"c", "c#", "d", "d#", "e", "f", "f#", "g", "g#", "a", "a#", "b"}
+
+// PitchClass returns the pitch class (0-11) of a note name without octave
+// (e.g. "c", "F#", "Bb"). Case is ignored.
+func PitchClass(name string) (uint8, error) {
+	switch strings.ToLower(name) {
+	case "c":
+		return 0, nil
+	case "c#", "db":
+		return 1, nil
+	case "d":
+		return 2, nil
+	case "d#", "eb":
+		return 3, nil
+	case "e":
+		return 4, nil
+	case "f":
+		return 5, nil
+	case "f#", "gb":
+		return 6, nil
+	case "g":
+		return 7, nil
+	case "g#", "ab":
+		return 8, nil
+	case "a":
+		return 9, nil
+	case "a#", "bb":
+		return 10, nil
+	case "b":
+		return 11, nil
+	}
+	return 0, fmt.Errorf("invalid note: %s", name)
+}
+
+// ScaleIntervals returns the intervals of the named scale
+func ScaleIntervals(scale string) ([]uint8, error) {
+	intervals, ok := scaleIntervals[scale]
+	if !ok {
+		return nil, fmt.Errorf("unknown scale: %s", scale)
+	}
+	return intervals, nil
+}
+
+// ScaleNotes returns every MIDI note from low to high (inclusive) that is in
+// the given key and scale
+func ScaleNotes(key, scale string, low, high uint8) ([]uint8, error) {
+	tonic, err := PitchClass(key)
+	if err != nil {
+		return nil, err
+	}
+	intervals, err := ScaleIntervals(scale)
+	if err != nil {
+		return nil, err
+	}
+
+	inScale := make([]bool, 12)
+	for _, interval := range intervals {
+		inScale[(tonic+interval)%12] = true
+	}
+
+	var notes []uint8
+	for n := int(low); n <= int(high) && n <= 127; n++ {
+		if inScale[n%12] {
+			notes = append(notes, uint8(n))
+		}
+	}
+	return notes, nil
+}
+
+// NoteName returns the name of a MIDI note number as used in parts (e.g. 60
+// returns "c4"). Notes below c0 (12) have no name.
+func NoteName(note uint8) (string, error) {
+	if note < 12 || note > 127 {
+		return "", fmt.Errorf("note out of range: %d", note)
+	}
+	return fmt.Sprintf("%s%d", noteNames[note%12], note/12-1), nil
+}
@@ -0,0 +1,110 @@
+package music
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPitchClass(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected uint8
+	}{
+		{"c", 0},
+		{"C", 0},
+		{"c#", 1},
+		{"Db", 1},
+		{"e", 4},
+		{"F#", 6},
+		{"gb", 6},
+		{"bb", 10},
+		{"b", 11},
+	}
+	for _, tt := range tests {
+		got, err := PitchClass(tt.name)
+		if err != nil {
+			t.Errorf("PitchClass(%q) unexpected error: %v", tt.name, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("PitchClass(%q) = %d, expected %d", tt.name, got, tt.expected)
+		}
+	}
+
+	for _, name := range []string{"", "h", "c4", "cb#"} {
+		if _, err := PitchClass(name); err == nil {
+			t.Errorf("PitchClass(%q) expected error, got nil", name)
+		}
+	}
+}
+
+func TestScaleNotes(t *testing.T) {
+	tests := []struct {
+		name      string
+		key       string
+		scale     string
+		low, high uint8
+		expected  []uint8
+	}{
+		{
+			name: "c major", key: "c", scale: "major", low: 60, high: 72,
+			expected: []uint8{60, 62, 64, 65, 67, 69, 71, 72},
+		},
+		{
+			name: "a minor", key: "a", scale: "minor", low: 57, high: 69,
+			expected: []uint8{57, 59, 60, 62, 64, 65, 67, 69},
+		},
+		{
+			// The range needn't start on the tonic
+			name: "d dorian from e", key: "d", scale: "dorian", low: 64, high: 70,
+			expected: []uint8{64, 65, 67, 69},
+		},
+		{
+			// Scales wrap around the octave when the tonic isn't c
+			name: "f# pentatonic", key: "f#", scale: "pentatonic", low: 60, high: 72,
+			expected: []uint8{61, 63, 66, 68, 70},
+		},
+		{
+			name: "top of the MIDI range", key: "g", scale: "major", low: 125, high: 127,
+			expected: []uint8{126, 127},
+		},
+		{
+			name: "empty range", key: "c", scale: "major", low: 61, high: 61,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ScaleNotes(tt.key, tt.scale, tt.low, tt.high)
+			if err != nil {
+				t.Fatalf("ScaleNotes() unexpected error: %v", err)
+			}
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("ScaleNotes() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestScaleNotesErrors(t *testing.T) {
+	if _, err := ScaleNotes("h", "major", 60, 72); err == nil {
+		t.Error("ScaleNotes() with unknown key expected error, got nil")
+	}
+	if _, err := ScaleNotes("c", "nope", 60, 72); err == nil {
+		t.Error("ScaleNotes() with unknown scale expected error, got nil")
+	}
+}
+
+func TestNoteName(t *testing.T) {
+	for note, expected := range map[uint8]string{12: "c0", 60: "c4", 61: "c#4", 127: "g9"} {
+		got, err := NoteName(note)
+		if err != nil || got != expected {
+			t.Errorf("NoteName(%d) = %q, %v, expected %q", note, got, err, expected)
+		}
+	}
+	for _, note := range []uint8{0, 11, 128} {
+		if _, err := NoteName(note); err == nil {
+			t.Errorf("NoteName(%d) expected error, got nil", note)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package sequence
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -15,10 +16,15 @@ type Arrangement struct {
 	Playables [][]Playable
 
 	currentStep *int
+	cue         *int // Step playback starts from
 
 	duration time.Duration
 
 	warnings []string
+
+	// origin is the arrangement a layer's copy was made from, which shows its
+	// steps
+	origin *Arrangement
 }
 
 func (a *Arrangement) parsePlayables(s Sequence) (err error) {
@@ -33,8 +39,13 @@ func (a *Arrangement) parsePlayables(s Sequence) (err error) {
 
 	matchPlayable:
 		for _, name := range sd.names() {
+			// a~ repeats part a to fill the step
+			name, fill := strings.CutSuffix(name, "~")
 			for _, p := range s.Playable {
 				if p.Name() == name {
+					if part, ok := p.(*Part); ok && (fill || part.fill) {
+						p = part.fillCopy()
+					}
 					a.Playables[stepIdx] = append(a.Playables[stepIdx], p)
 					continue matchPlayable
 				}
@@ -71,37 +82,117 @@ func (a *Arrangement) parsePlayables(s Sequence) (err error) {
 // number of beats which ensures each step is timed correctly.
 //
 // It also carries all off messages so they can be sent at the last possible
-// beat of the step where the note they control ends.
+// beat of the step where the note they control ends, along with any messages
+// that start between steps.
 func (a *Arrangement) appendSyncParts() {
-
 	for i, stepPlayables := range a.Playables {
-		var mostBeats int
-		for _, playable := range stepPlayables {
-			switch playable := playable.(type) {
-			case *Part:
-				part := playable
-				beats := len(part.StepMIDI) * part.Div()
-				if beats > mostBeats {
-					mostBeats = beats
+		fillStep(stepPlayables)
+		a.Playables[i] = append(a.Playables[i], syncPart(stepPlayables))
+	}
+}
+
+// updateSyncParts rebuilds the sync parts after parts have changed
+func (a *Arrangement) updateSyncParts() {
+	for _, stepPlayables := range a.Playables {
+		last := len(stepPlayables) - 1
+		fillStep(stepPlayables[:last])
+		stepPlayables[last] = syncPart(stepPlayables[:last])
+	}
+}
+
+// syncPart builds the sync part for the playables of an arrangement step
+func syncPart(stepPlayables []Playable) *Part {
+	var mostBeats int
+	for _, playable := range stepPlayables {
+		switch playable := playable.(type) {
+		case *Part:
+			part := playable
+			beats := len(part.StepMIDI) * part.Div()
+			if beats > mostBeats {
+				mostBeats = beats
+			}
+		case *Arrangement:
+			// Skip arrangements - they handle their own timing recursively
+			continue
+		}
+	}
+	p := &Part{
+		div:      1,
+		StepMIDI: make([]partStep, mostBeats),
+	}
+	for _, playable := range stepPlayables {
+		// Only aggregate offMessages from Parts, skip Arrangements
+		if part, ok := playable.(*Part); ok {
+			for i, msgs := range part.offMessages {
+				p.StepMIDI[i].Off = append(p.StepMIDI[i].Off, msgs...)
+			}
+		}
+	}
+	return p
+}
+
+// Regenerate is called when playback starts a pass of the arrangement. Parts
+// with regen, including those in nested arrangements, re-run their generator
+// when a new seed is due.
+func (a *Arrangement) Regenerate() error {
+	seen := map[*Part]bool{}
+	arrangements := a.nested()
+	for _, arr := range arrangements {
+		for _, stepPlayables := range arr.Playables {
+			for _, playable := range stepPlayables {
+				part, ok := playable.(*Part)
+				if ok && part.source != nil {
+					part = part.source
+				}
+				if !ok || part.regen == nil || seen[part] {
+					continue
+				}
+				seen[part] = true
+				if err := part.regenerate(part.regen.pass()); err != nil {
+					return fmt.Errorf("%s: %w", part.name, err)
 				}
-			case *Arrangement:
-				// Skip arrangements - they handle their own timing recursively
-				continue
 			}
 		}
-		p := &Part{
-			div:      1,
-			StepMIDI: make([]partStep, mostBeats),
+	}
+	if len(seen) > 0 {
+		for _, arr := range arrangements {
+			arr.updateSyncParts()
+		}
+	}
+	return nil
+}
+
+// nested returns the arrangement and every arrangement nested in it, once
+// each
+func (a *Arrangement) nested() []*Arrangement {
+	arrangements := []*Arrangement{a}
+	for i := 0; i < len(arrangements); i++ {
+		for _, stepPlayables := range arrangements[i].Playables {
+			for _, playable := range stepPlayables {
+				if n, ok := playable.(*Arrangement); ok && !slices.Contains(arrangements, n) {
+					arrangements = append(arrangements, n)
+				}
+			}
 		}
+	}
+	return arrangements
+}
+
+// ResetPasses restarts the passes of every part, including those in nested
+// arrangements. It's called when playback starts.
+func (a *Arrangement) ResetPasses() {
+	for _, stepPlayables := range a.Playables {
 		for _, playable := range stepPlayables {
-			// Only aggregate offMessages from Parts, skip Arrangements
-			if part, ok := playable.(*Part); ok {
-				for i, msgs := range part.offMessages {
-					p.StepMIDI[i].Off = append(p.StepMIDI[i].Off, msgs...)
+			switch p := playable.(type) {
+			case *Part:
+				if p.source != nil {
+					p = p.source
 				}
+				p.resetPasses()
+			case *Arrangement:
+				p.ResetPasses()
 			}
 		}
-		a.Playables[i] = append(a.Playables[i], p)
 	}
 }
 
@@ -123,6 +214,8 @@ func (a *Arrangement) Steps() (s string) {
 		current := " "
 		if a.currentStep != nil && *a.currentStep == i {
 			current = ">"
+		} else if a.cue != nil && *a.cue == i {
+			current = "*"
 		}
 		steps = append(steps, fmt.Sprintf("%s %*d  %s", current, len(strconv.Itoa(len(a.steps))), i+1, step))
 	}
@@ -131,17 +224,91 @@ func (a *Arrangement) Steps() (s string) {
 }
 
 func (a *Arrangement) CurrentStep() *int {
+	if a.origin != nil {
+		return a.origin.CurrentStep()
+	}
 	return a.currentStep
 }
 
 func (a *Arrangement) UpdateStep(i int) {
+	if a.origin != nil {
+		a.origin.UpdateStep(i)
+		return
+	}
 	a.currentStep = &i
 }
 
 func (a *Arrangement) ClearStep() {
+	if a.origin != nil {
+		a.origin.ClearStep()
+		return
+	}
 	a.currentStep = nil
 }
 
+// Parts returns the parts in the arrangement, including those in nested
+// arrangements, once each
+func (a *Arrangement) Parts() []*Part {
+	var parts []*Part
+	for _, arr := range a.nested() {
+		for _, stepPlayables := range arr.Playables {
+			for _, playable := range stepPlayables {
+				part, ok := playable.(*Part)
+				if !ok || part.name == "" {
+					continue
+				}
+				if part.source != nil {
+					part = part.source
+				}
+				if !slices.Contains(parts, part) {
+					parts = append(parts, part)
+				}
+			}
+		}
+	}
+	return parts
+}
+
+// ClearSteps clears the current step of the arrangement and everything in it
+func (a *Arrangement) ClearSteps() {
+	for _, arr := range a.nested() {
+		arr.ClearStep()
+		for _, stepPlayables := range arr.Playables {
+			for _, playable := range stepPlayables {
+				if part, ok := playable.(*Part); ok {
+					if part.source != nil {
+						part = part.source
+					}
+					part.ClearStep()
+				}
+			}
+		}
+	}
+}
+
+// Cue returns the step playback starts from, or nil to start from the first
+func (a *Arrangement) Cue() *int {
+	return a.cue
+}
+
+// MoveCue moves the cue by delta steps, staying within the arrangement. The
+// cue starts on the first step.
+func (a *Arrangement) MoveCue(delta int) {
+	if len(a.steps) == 0 {
+		return
+	}
+	i := 0
+	if a.cue != nil {
+		i = min(max(*a.cue+delta, 0), len(a.steps)-1)
+	}
+	a.cue = &i
+}
+
+// ClearCue starts playback from the first step again
+func (a *Arrangement) ClearCue() {
+	a.cue = nil
+}
+
 func (a *Arrangement) Warnings() []string {
 	return a.warnings
 }
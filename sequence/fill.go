@@ -0,0 +1,106 @@
+package sequence
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+
+	"github.com/odaacabeef/beefdown/midi"
+)
+
+// fillCopy returns a copy of the part for an arrangement step. It's filled
+// when the step's sync part is built.
+func (p *Part) fillCopy() *Part {
+	return &Part{
+		name:    p.name,
+		group:   p.group,
+		channel: p.channel,
+		div:     p.div,
+		drumMap: p.drumMap,
+		source:  p,
+	}
+}
+
+// fillTo rebuilds a fill copy from its source, repeating the source for the
+// given number of clock ticks. A partial repeat at the end is cut off: notes
+// still sounding are sent note offs on the last tick.
+func (p *Part) fillTo(ticks int) {
+	src := p.source
+	srcTicks := len(src.StepMIDI) * src.Div()
+
+	p.ticks = ticks
+	p.StepMIDI = nil
+	p.offMessages = map[int][][]byte{}
+	if srcTicks == 0 {
+		return
+	}
+
+	p.StepMIDI = make([]partStep, (ticks+p.div-1)/p.div)
+	for i := range p.StepMIDI {
+		p.StepMIDI[i] = src.StepMIDI[i%len(src.StepMIDI)]
+	}
+	for start := 0; start < ticks; start += srcTicks {
+		for t, msgs := range src.offMessages {
+			if start+t < ticks {
+				p.offMessages[start+t] = append(p.offMessages[start+t], msgs...)
+			}
+		}
+	}
+
+	// Find notes that may be sounding at the end of the step, including
+	// notes with conditions. Off messages are counted before on messages on
+	// the same tick.
+	on := map[int][][]byte{}
+	for i, step := range p.StepMIDI {
+		start := i * p.div
+		on[start] = slices.Concat(on[start], step.Off, step.On)
+		subs := []map[int][][]byte{step.Sub}
+		for _, t := range step.Trigs {
+			on[start] = append(on[start], t.on...)
+			subs = append(subs, t.sub)
+		}
+		for _, sub := range subs {
+			for t, msgs := range sub {
+				on[start+t] = append(on[start+t], msgs...)
+			}
+		}
+	}
+	sounding := map[[2]byte]bool{}
+	for t := range ticks {
+		for _, m := range slices.Concat(p.offMessages[t], on[t]) {
+			switch {
+			case midi.IsNoteOff(m):
+				delete(sounding, [2]byte{m[0] & 0x0F, m[1]})
+			case midi.IsNoteOn(m):
+				sounding[[2]byte{m[0] & 0x0F, m[1]}] = true
+			}
+		}
+	}
+	notes := slices.SortedFunc(maps.Keys(sounding), func(a, b [2]byte) int {
+		return cmp.Or(cmp.Compare(a[0], b[0]), cmp.Compare(a[1], b[1]))
+	})
+	for _, n := range notes {
+		p.offMessages[ticks-1] = append(p.offMessages[ticks-1], midi.NoteOff(n[0], n[1], 0))
+	}
+}
+
+// fillStep fills the fill copies in an arrangement step to the length of the
+// step, which is the length of its longest part
+func fillStep(stepPlayables []Playable) {
+	var ticks int
+	var fills []*Part
+	for _, playable := range stepPlayables {
+		part, ok := playable.(*Part)
+		if !ok {
+			continue
+		}
+		if part.source != nil {
+			fills = append(fills, part)
+			part = part.source
+		}
+		ticks = max(ticks, len(part.StepMIDI)*part.Div())
+	}
+	for _, part := range fills {
+		part.fillTo(ticks)
+	}
+}
@@ -0,0 +1,51 @@
+package sequence
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestArrangementFill(t *testing.T) {
+	md := "```beef.part name:four\nc2:1\nc2:1\nc2:1\nc2:1\n```\n\n" +
+		"```beef.part name:three\nc4:2\n\ne4:1\n```\n\n" +
+		"```beef.part name:two loop:fill\nc5:1\n\n```\n\n" +
+		"```beef.arrangement name:a\nfour three~ two\n```\n"
+	path := filepath.Join(t.TempDir(), "fill.md")
+	if err := os.WriteFile(path, []byte(md), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(path)
+	if err != nil {
+		t.Fatalf("New() unexpected error: %v", err)
+	}
+	step := s.Arrangements[0].Playables[0]
+
+	// three and two are repeated to the length of four
+	for i, steps := range []int{4, 4, 4} {
+		if n := len(step[i].(*Part).StepMIDI); n != steps {
+			t.Errorf("%s has %d steps, want %d", step[i].Name(), n, steps)
+		}
+	}
+	three := step[1].(*Part)
+	if want := [][]byte{{0x90, 60, 100}}; !reflect.DeepEqual(three.StepMIDI[3].On, want) {
+		t.Errorf("three step 4 On = %v, want %v", three.StepMIDI[3].On, want)
+	}
+
+	// The second c4 is cut off at the end of the step
+	sync := step[3].(*Part)
+	want := [][]byte{{0x80, 36, 0}, {0x80, 60, 0}}
+	if got := sync.StepMIDI[95].Off; !reflect.DeepEqual(got, want) {
+		t.Errorf("sync Off at tick 95 = %v, want %v", got, want)
+	}
+	if got := sync.StepMIDI[71].Off; len(got) != 3 {
+		t.Errorf("sync Off at tick 71 = %v, want c2, e4 and c5 note offs", got)
+	}
+
+	// Filled parts update the steps of the original part
+	three.UpdateStep(3)
+	if p := s.Parts[1].CurrentStep(); p == nil || *p != 0 {
+		t.Errorf("three current step = %v, want 0", p)
+	}
+}
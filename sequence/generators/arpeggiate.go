@@ -26,16 +26,13 @@ func (a *Arpeggiate) Generate() ([]string, error) {
 	return steps, nil
 }
 
-func newArpeggiate(meta metaparser.PartMetadata, params map[string]interface{}) (Generator, error) {
+func newArpeggiate(meta metaparser.PartMetadata, params map[string]interface{}, env Env) (Generator, error) {
 	notes, ok := getStringParam(params, "notes")
 	if !ok {
 		return nil, fmt.Errorf("arpeggiate: missing required parameter 'notes'")
 	}
 
-	length, ok := getIntParam(params, "length")
-	if !ok {
-		length = 1 // default
-	}
+	length, _ := getIntParam(params, "length")
 
 	return &Arpeggiate{
 		Notes:  notes,
@@ -44,5 +41,13 @@ func newArpeggiate(meta metaparser.PartMetadata, params map[string]interface{})
 }
 
 func init() {
-	Register("arpeggiate", newArpeggiate)
+	Register(Spec{
+		Name:        "arpeggiate",
+		Description: "Cycles through a list of notes, one per step",
+		Params: []Param{
+			{Name: "notes", Type: StringParam, Required: true, Description: "Comma-separated notes (e.g. c4,e4,g4)"},
+			{Name: "length", Type: IntParam, Default: "1", Min: bound(0), Description: "Number of steps"},
+		},
+		Factory: newArpeggiate,
+	})
 }
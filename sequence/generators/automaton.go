@@ -0,0 +1,138 @@
+package generators
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+
+	metaparser "github.com/odaacabeef/beefdown/sequence/parsers/metadata"
+)
+
+// Automaton generates steps from an elementary cellular automaton
+// Each generation is one step. Every cell has a note, and live cells are hits.
+type Automaton struct {
+	Rule        int
+	Width       int
+	Generations int
+	Notes       []string // One note per cell, cycled if shorter than Width
+	Init        string   // "center" (single live cell) or "random"
+	Seed        int64
+}
+
+func (a *Automaton) Generate() ([]string, error) {
+	if a.Rule < 0 || a.Rule > 255 {
+		return nil, fmt.Errorf("automaton: rule must be between 0 and 255")
+	}
+	if a.Width < 1 {
+		return nil, fmt.Errorf("automaton: width must be at least 1")
+	}
+	if a.Generations < 0 {
+		return nil, fmt.Errorf("automaton: generations must be non-negative")
+	}
+	if len(a.Notes) == 0 {
+		return nil, fmt.Errorf("automaton: notes are required")
+	}
+
+	cells := make([]bool, a.Width)
+	switch a.Init {
+	case "center":
+		cells[a.Width/2] = true
+	case "random":
+		rng := rand.New(rand.NewSource(a.Seed))
+		for i := range cells {
+			cells[i] = rng.Intn(2) == 1
+		}
+	default:
+		return nil, fmt.Errorf("automaton: init must be center or random")
+	}
+
+	var steps []string
+	for range a.Generations {
+		var hits []string
+		for i, live := range cells {
+			hit := fmt.Sprintf("%s:1", a.Notes[i%len(a.Notes)])
+			// Cells sharing a note only play it once
+			if live && !slices.Contains(hits, hit) {
+				hits = append(hits, hit)
+			}
+		}
+		steps = append(steps, strings.Join(hits, " "))
+		cells = a.next(cells)
+	}
+
+	return steps, nil
+}
+
+// next applies the rule to every cell. The edges wrap around.
+func (a *Automaton) next(cells []bool) []bool {
+	next := make([]bool, len(cells))
+	for i := range cells {
+		pattern := 0
+		if cells[(i-1+len(cells))%len(cells)] {
+			pattern |= 4
+		}
+		if cells[i] {
+			pattern |= 2
+		}
+		if cells[(i+1)%len(cells)] {
+			pattern |= 1
+		}
+		next[i] = a.Rule&(1<<pattern) != 0
+	}
+	return next
+}
+
+func newAutomaton(meta metaparser.PartMetadata, params map[string]interface{}, env Env) (Generator, error) {
+	rule, ok := getIntParam(params, "rule")
+	if !ok {
+		return nil, fmt.Errorf("automaton: missing required parameter 'rule'")
+	}
+
+	generations, ok := getIntParam(params, "generations")
+	if !ok {
+		return nil, fmt.Errorf("automaton: missing required parameter 'generations'")
+	}
+
+	notes, ok := getStringParam(params, "notes")
+	if !ok {
+		return nil, fmt.Errorf("automaton: missing required parameter 'notes'")
+	}
+	var pool []string
+	for _, n := range strings.Split(notes, ",") {
+		pool = append(pool, strings.TrimSpace(n))
+	}
+
+	width, ok := getIntParam(params, "width")
+	if !ok {
+		width = len(pool) // defaults to a cell for each note
+	}
+
+	initState, _ := getStringParam(params, "init")
+	seed, _ := getIntParam(params, "seed")
+
+	return &Automaton{
+		Rule:        rule,
+		Width:       width,
+		Generations: generations,
+		Notes:       pool,
+		Init:        initState,
+		Seed:        int64(seed),
+	}, nil
+}
+
+func init() {
+	Register(Spec{
+		Name:        "automaton",
+		Description: "Plays an elementary cellular automaton, one generation per step",
+		Params: []Param{
+			{Name: "rule", Type: IntParam, Required: true, Min: bound(0), Max: bound(255), Description: "Wolfram rule number"},
+			{Name: "generations", Type: IntParam, Required: true, Min: bound(0), Description: "Number of steps"},
+			{Name: "notes", Type: StringParam, Required: true, Description: "Comma-separated notes, one per cell"},
+			{Name: "width", Type: IntParam, Min: bound(1), Description: "Number of cells; defaults to the number of notes"},
+			{Name: "init", Type: StringParam, Default: "center", Description: "First generation: center or random"},
+			{Name: "seed", Type: IntParam, Default: "0", Description: "Random seed"},
+		},
+		Factory: newAutomaton,
+	})
+}
@@ -0,0 +1,130 @@
+package generators
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// cells reads a row of cells written as 0s and 1s
+func cells(row string) []bool {
+	var c []bool
+	for _, r := range row {
+		c = append(c, r == '1')
+	}
+	return c
+}
+
+func TestAutomatonNext(t *testing.T) {
+	tests := []struct {
+		name string
+		rule int
+		rows []string // Each row is the generation after the one before
+	}{
+		{
+			name: "rule 30",
+			rule: 30,
+			rows: []string{"0001000", "0011100", "0110010", "1101111"},
+		},
+		{
+			name: "rule 90",
+			rule: 90,
+			rows: []string{"0001000", "0010100", "0100010", "1010101"},
+		},
+		{
+			name: "rule 110",
+			rule: 110,
+			rows: []string{"0001000", "0011000", "0111000", "1101000"},
+		},
+		{
+			// Edge cells are neighbours of each other
+			name: "wraparound",
+			rule: 30,
+			rows: []string{"010", "111", "000"},
+		},
+		{
+			// A single cell is its own left and right neighbour
+			name: "single cell",
+			rule: 128,
+			rows: []string{"1", "1"},
+		},
+		{
+			name: "rule 0",
+			rule: 0,
+			rows: []string{"1011", "0000"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &Automaton{Rule: tt.rule}
+			for i := 1; i < len(tt.rows); i++ {
+				got := a.next(cells(tt.rows[i-1]))
+				if !slices.Equal(got, cells(tt.rows[i])) {
+					t.Errorf("next(%s) = %v, expected %s", tt.rows[i-1], got, tt.rows[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAutomaton(t *testing.T) {
+	tests := []struct {
+		name      string
+		automaton Automaton
+		expected  []string
+	}{
+		{
+			name: "rule 30",
+			automaton: Automaton{
+				Rule: 30, Width: 5, Generations: 3, Init: "center",
+				Notes: []string{"c4", "d4", "e4", "f4", "g4"},
+			},
+			expected: []string{"e4:1", "d4:1 e4:1 f4:1", "c4:1 d4:1 g4:1"},
+		},
+		{
+			// Notes are cycled across cells, and play once per step
+			name: "shared notes",
+			automaton: Automaton{
+				Rule: 30, Width: 5, Generations: 3, Init: "center",
+				Notes: []string{"c1", "d1"},
+			},
+			expected: []string{"c1:1", "d1:1 c1:1", "c1:1 d1:1"},
+		},
+		{
+			name: "no generations",
+			automaton: Automaton{
+				Rule: 30, Width: 5, Init: "center", Notes: []string{"c4"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			steps, err := tt.automaton.Generate()
+			if err != nil {
+				t.Fatalf("Generate() unexpected error: %v", err)
+			}
+			if !slices.Equal(steps, tt.expected) {
+				t.Errorf("Generate() = %q, expected %q", steps, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAutomatonRandom(t *testing.T) {
+	generate := func(seed int64) string {
+		a := &Automaton{Rule: 30, Width: 16, Generations: 8, Init: "random", Notes: []string{"c4", "d4", "e4", "f4"}, Seed: seed}
+		steps, err := a.Generate()
+		if err != nil {
+			t.Fatalf("Generate() unexpected error: %v", err)
+		}
+		return strings.Join(steps, "|")
+	}
+	if generate(3) != generate(3) {
+		t.Error("Generate() with the same seed returned different steps")
+	}
+	if generate(3) == generate(4) {
+		t.Error("Generate() with a different seed returned the same steps")
+	}
+}
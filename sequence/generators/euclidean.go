@@ -126,7 +126,7 @@ func rotate(pattern []bool, amount int) []bool {
 	return rotated
 }
 
-func newEuclidean(meta metaparser.PartMetadata, params map[string]interface{}) (Generator, error) {
+func newEuclidean(meta metaparser.PartMetadata, params map[string]interface{}, env Env) (Generator, error) {
 	pulses, ok := getIntParam(params, "pulses")
 	if !ok {
 		return nil, fmt.Errorf("euclidean: missing required parameter 'pulses'")
@@ -146,8 +146,8 @@ func newEuclidean(meta metaparser.PartMetadata, params map[string]interface{}) (
 		}
 	}
 
-	rotation, _ := getIntParam(params, "rotation") // optional, defaults to 0
-	seed, _ := getIntParam(params, "seed")         // optional, defaults to 0
+	rotation, _ := getIntParam(params, "rotation")
+	seed, _ := getIntParam(params, "seed")
 
 	return &Euclidean{
 		Pulses:   pulses,
@@ -159,5 +159,17 @@ func newEuclidean(meta metaparser.PartMetadata, params map[string]interface{}) (
 }
 
 func init() {
-	Register("euclidean", newEuclidean)
+	Register(Spec{
+		Name:        "euclidean",
+		Description: "Spreads pulses as evenly as possible across steps",
+		Params: []Param{
+			{Name: "pulses", Type: IntParam, Required: true, Min: bound(0), Description: "Number of hits"},
+			{Name: "steps", Type: IntParam, Required: true, Min: bound(0), Description: "Number of steps"},
+			{Name: "note", Type: StringParam, Description: "Note played on each hit (or notes)"},
+			{Name: "notes", Type: StringParam, Description: "Alternative name for note"},
+			{Name: "rotation", Type: IntParam, Default: "0", Description: "Steps to rotate the pattern by"},
+			{Name: "seed", Type: IntParam, Default: "0", Description: "Random seed"},
+		},
+		Factory: newEuclidean,
+	})
 }
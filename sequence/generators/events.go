@@ -0,0 +1,93 @@
+package generators
+
+import (
+	"fmt"
+
+	partparser "github.com/odaacabeef/beefdown/sequence/parsers/part"
+)
+
+// EventType identifies the kind of MIDI message an Event sends
+type EventType int
+
+const (
+	NoteEvent EventType = iota
+	CCEvent
+)
+
+// Event is a MIDI event in a generated part. Positions and lengths are in
+// clock ticks (24 per quarter note) from the start of the part, so events
+// aren't limited to step boundaries.
+type Event struct {
+	Type    EventType
+	Tick    int
+	Channel uint8 // 1-16; 0 uses the part's channel
+
+	// NoteEvent
+	Note     uint8
+	Velocity uint8
+	Length   int // Ticks until the note off; 0 sends no note off
+
+	// CCEvent
+	Controller uint8
+	Value      uint8
+
+	// Condition decides during playback whether the event is sent, like the
+	// conditions of a part's notes. Events with the same non-zero Trig share
+	// one decision (e.g. the notes and hits of a chord).
+	Condition partparser.Condition
+	Trig      int
+}
+
+// Check returns an error if a field of the event is out of range for MIDI
+func (e Event) Check() error {
+	switch {
+	case e.Channel > 16:
+		return fmt.Errorf("channel %d is outside 1-16", e.Channel)
+	case e.Note > 127:
+		return fmt.Errorf("note %d is outside 0-127", e.Note)
+	case e.Velocity > 127:
+		return fmt.Errorf("velocity %d is outside 0-127", e.Velocity)
+	case e.Controller > 127:
+		return fmt.Errorf("controller %d is outside 0-127", e.Controller)
+	case e.Value > 127:
+		return fmt.Errorf("value %d is outside 0-127", e.Value)
+	}
+	return nil
+}
+
+// Events is the output of an EventGenerator
+type Events struct {
+	Steps  int      // Length of the part in steps
+	Text   []string // Optional text displayed for each step
+	Events []Event
+}
+
+// EventGenerator is implemented by generators that produce typed events
+type EventGenerator interface {
+	GenerateEvents() (Events, error)
+}
+
+// StepParser converts step text to events like the steps of a part
+type StepParser func(steps []string) (Events, error)
+
+// Adapt returns g as an EventGenerator. Generators that produce events are
+// returned as is. Step text from other generators is converted with parse.
+func Adapt(g Generator, parse StepParser) EventGenerator {
+	if eg, ok := g.(EventGenerator); ok {
+		return eg
+	}
+	return &stepAdapter{gen: g, parse: parse}
+}
+
+type stepAdapter struct {
+	gen   Generator
+	parse StepParser
+}
+
+func (s *stepAdapter) GenerateEvents() (Events, error) {
+	steps, err := s.gen.Generate()
+	if err != nil {
+		return Events{}, err
+	}
+	return s.parse(steps)
+}
@@ -0,0 +1,260 @@
+package generators
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	metaparser "github.com/odaacabeef/beefdown/sequence/parsers/metadata"
+)
+
+// Exec runs an external program as a generator
+// The block's params (and any parts listed in the parts param) are written to
+// the program's stdin as JSON. The program writes step text or typed events to
+// stdout as JSON.
+type Exec struct {
+	Cmd     []string
+	Dir     string
+	Timeout time.Duration
+	Input   ExecInput
+	Steps   StepParser // Converts steps the program returns to events
+}
+
+// ExecInput is written to the program's stdin
+type ExecInput struct {
+	Name    string              `json:"name"`
+	Group   string              `json:"group"`
+	Channel uint8               `json:"channel"`
+	Div     int                 `json:"div"`
+	Params  map[string]any      `json:"params"`
+	Parts   map[string][]string `json:"parts"`
+}
+
+// ExecOutput is read from the program's stdout. Programs either return steps
+// (part step text) or events with the part's length in steps.
+type ExecOutput struct {
+	Steps  []string    `json:"steps"`
+	Length int         `json:"length"`
+	Events []ExecEvent `json:"events"`
+}
+
+// ExecEvent is an Event as read from JSON. Type is "note" or "cc". Notes
+// without a velocity are sent with a velocity of 100, like parts.
+type ExecEvent struct {
+	Type       string `json:"type"`
+	Tick       int    `json:"tick"`
+	Channel    int    `json:"channel"`
+	Note       int    `json:"note"`
+	Velocity   *int   `json:"velocity"`
+	Length     int    `json:"length"`
+	Controller int    `json:"controller"`
+	Value      int    `json:"value"`
+}
+
+// velocity returns the event's velocity, or 100 if it has none
+func (ev ExecEvent) velocity() int {
+	if ev.Velocity == nil {
+		return 100
+	}
+	return *ev.Velocity
+}
+
+// check returns an error if a field of the event is out of range for MIDI
+func (ev ExecEvent) check() error {
+	if ev.Channel < 0 || ev.Channel > 16 {
+		return fmt.Errorf("channel %d is outside 1-16", ev.Channel)
+	}
+	fields := []struct {
+		name  string
+		value int
+	}{
+		{"note", ev.Note},
+		{"velocity", ev.velocity()},
+		{"controller", ev.Controller},
+		{"value", ev.Value},
+	}
+	for _, f := range fields {
+		if f.value < 0 || f.value > 127 {
+			return fmt.Errorf("%s %d is outside 0-127", f.name, f.value)
+		}
+	}
+	return nil
+}
+
+func (e *Exec) Generate() ([]string, error) {
+	out, err := e.run()
+	if err != nil {
+		return nil, err
+	}
+	if out.Events != nil {
+		return nil, fmt.Errorf("exec %s: returned events where steps were expected", e.Cmd[0])
+	}
+	return out.Steps, nil
+}
+
+func (e *Exec) GenerateEvents() (Events, error) {
+	out, err := e.run()
+	if err != nil {
+		return Events{}, err
+	}
+
+	if out.Events == nil {
+		return e.Steps(out.Steps)
+	}
+
+	if out.Length < 1 {
+		return Events{}, fmt.Errorf("exec %s: length is required with events", e.Cmd[0])
+	}
+	var events []Event
+	for i, ev := range out.Events {
+		var t EventType
+		switch ev.Type {
+		case "note", "":
+			t = NoteEvent
+		case "cc":
+			t = CCEvent
+		default:
+			return Events{}, fmt.Errorf("exec %s: event %d: unknown type %q", e.Cmd[0], i, ev.Type)
+		}
+		if err := ev.check(); err != nil {
+			return Events{}, fmt.Errorf("exec %s: event %d: %w", e.Cmd[0], i, err)
+		}
+		events = append(events, Event{
+			Type:       t,
+			Tick:       ev.Tick,
+			Channel:    uint8(ev.Channel),
+			Note:       uint8(ev.Note),
+			Velocity:   uint8(ev.velocity()),
+			Length:     ev.Length,
+			Controller: uint8(ev.Controller),
+			Value:      uint8(ev.Value),
+		})
+	}
+
+	return Events{
+		Steps:  out.Length,
+		Text:   out.Steps,
+		Events: events,
+	}, nil
+}
+
+// run executes the program and decodes its output
+func (e *Exec) run() (ExecOutput, error) {
+	input, err := json.Marshal(e.Input)
+	if err != nil {
+		return ExecOutput{}, fmt.Errorf("exec %s: %w", e.Cmd[0], err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.Timeout)
+	defer cancel()
+
+	// Relative program paths are relative to the sequence file
+	name := e.Cmd[0]
+	if strings.Contains(name, "/") && !filepath.IsAbs(name) {
+		name = filepath.Join(e.Dir, name)
+	}
+
+	cmd := exec.CommandContext(ctx, name, e.Cmd[1:]...)
+	killGroup(cmd)
+	// Programs it started that keep its output open are only waited on
+	// briefly after a timeout
+	cmd.WaitDelay = time.Second
+	cmd.Dir = e.Dir
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return ExecOutput{}, fmt.Errorf("exec %s: timed out after %s", e.Cmd[0], e.Timeout)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return ExecOutput{}, fmt.Errorf("exec %s: %w: %s", e.Cmd[0], err, msg)
+		}
+		return ExecOutput{}, fmt.Errorf("exec %s: %w", e.Cmd[0], err)
+	}
+
+	var out ExecOutput
+	dec := json.NewDecoder(&stdout)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&out); err != nil {
+		return ExecOutput{}, fmt.Errorf("exec %s: invalid output: %w", e.Cmd[0], err)
+	}
+	return out, nil
+}
+
+func newExec(meta metaparser.PartMetadata, params map[string]interface{}, env Env) (Generator, error) {
+	cmd, ok := getStringParam(params, "cmd")
+	if !ok {
+		return nil, fmt.Errorf("exec: missing required parameter 'cmd'")
+	}
+	args := strings.Fields(cmd)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("exec: cmd is empty")
+	}
+
+	timeout, _ := getIntParam(params, "timeout")
+	if timeout < 1 {
+		return nil, fmt.Errorf("exec: timeout must be at least 1ms")
+	}
+
+	input := ExecInput{
+		Name:    meta.Name,
+		Group:   meta.Group,
+		Channel: meta.Channel,
+		Div:     meta.Div,
+		Params:  map[string]any{},
+		Parts:   map[string][]string{},
+	}
+	for key, value := range params {
+		switch v := value.(type) {
+		case *metaparser.StringNode:
+			input.Params[key] = v.Value
+		case *metaparser.NumberNode:
+			input.Params[key] = v.Value
+		case *metaparser.BooleanNode:
+			input.Params[key] = v.Value
+		}
+	}
+
+	if names, ok := getStringParam(params, "parts"); ok {
+		for _, name := range strings.Split(names, ",") {
+			name = strings.TrimSpace(name)
+			steps, ok := env.Parts(name)
+			if !ok {
+				return nil, fmt.Errorf("exec: part %q not found", name)
+			}
+			input.Parts[name] = steps
+		}
+	}
+
+	return &Exec{
+		Cmd:     args,
+		Dir:     env.Dir,
+		Timeout: time.Duration(timeout) * time.Millisecond,
+		Input:   input,
+		Steps:   env.Steps,
+	}, nil
+}
+
+func init() {
+	Register(Spec{
+		Name:        "exec",
+		Description: "Runs an external program; other params are passed to it",
+		Params: []Param{
+			{Name: "cmd", Type: StringParam, Required: true, Description: "Program and arguments"},
+			{Name: "timeout", Type: IntParam, Default: "5000", Min: bound(1), Description: "Milliseconds to wait for output"},
+			{Name: "parts", Type: StringParam, Description: "Comma-separated names of parts passed to the program"},
+		},
+		AnyParams: true,
+		Factory:   newExec,
+	})
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package generators
+
+import "os/exec"
+
+// killGroup does nothing where process groups aren't available. The program
+// is killed on its own.
+func killGroup(cmd *exec.Cmd) {}
@@ -0,0 +1,120 @@
+package generators
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// execOutput returns an Exec running a script that writes output
+func execOutput(t *testing.T, output string) *Exec {
+	t.Helper()
+	dir := t.TempDir()
+	script := "#!/bin/sh\ncat > /dev/null\ncat <<'EOF'\n" + output + "\nEOF\n"
+	if err := os.WriteFile(filepath.Join(dir, "gen.sh"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return &Exec{
+		Cmd:     []string{"./gen.sh"},
+		Dir:     dir,
+		Timeout: 5 * time.Second,
+	}
+}
+
+func TestExecEvents(t *testing.T) {
+	e := execOutput(t, `{"length": 2, "events": [
+		{"type": "note", "tick": 0, "note": 60, "length": 12},
+		{"type": "note", "tick": 6, "channel": 16, "note": 64, "velocity": 0},
+		{"type": "cc", "tick": 24, "controller": 74, "value": 127}
+	]}`)
+	events, err := e.GenerateEvents()
+	if err != nil {
+		t.Fatalf("GenerateEvents() unexpected error: %v", err)
+	}
+	expected := []Event{
+		{Type: NoteEvent, Tick: 0, Note: 60, Velocity: 100, Length: 12},
+		{Type: NoteEvent, Tick: 6, Channel: 16, Note: 64, Velocity: 0},
+		{Type: CCEvent, Tick: 24, Velocity: 100, Controller: 74, Value: 127},
+	}
+	if events.Steps != 2 || len(events.Events) != len(expected) {
+		t.Fatalf("GenerateEvents() = %+v, expected %d events over 2 steps", events, len(expected))
+	}
+	for i := range expected {
+		if events.Events[i] != expected[i] {
+			t.Errorf("event %d = %+v, expected %+v", i, events.Events[i], expected[i])
+		}
+	}
+}
+
+func TestExecEventsOutOfRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		event    string
+		expected string
+	}{
+		{
+			name:     "channel",
+			event:    `{"note": 60, "channel": 20}`,
+			expected: "exec ./gen.sh: event 1: channel 20 is outside 1-16",
+		},
+		{
+			name:     "note",
+			event:    `{"note": 200}`,
+			expected: "exec ./gen.sh: event 1: note 200 is outside 0-127",
+		},
+		{
+			name:     "velocity",
+			event:    `{"note": 60, "velocity": 200}`,
+			expected: "exec ./gen.sh: event 1: velocity 200 is outside 0-127",
+		},
+		{
+			name:     "negative velocity",
+			event:    `{"note": 60, "velocity": -1}`,
+			expected: "exec ./gen.sh: event 1: velocity -1 is outside 0-127",
+		},
+		{
+			name:     "controller",
+			event:    `{"type": "cc", "controller": 128}`,
+			expected: "exec ./gen.sh: event 1: controller 128 is outside 0-127",
+		},
+		{
+			name:     "value",
+			event:    `{"type": "cc", "controller": 1, "value": 300}`,
+			expected: "exec ./gen.sh: event 1: value 300 is outside 0-127",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := execOutput(t, `{"length": 1, "events": [{"note": 60}, `+tt.event+`]}`)
+			_, err := e.GenerateEvents()
+			if err == nil || err.Error() != tt.expected {
+				t.Errorf("GenerateEvents() error = %v, expected %q", err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExecTimeout(t *testing.T) {
+	// The program starts another that keeps its output open
+	dir := t.TempDir()
+	script := "#!/bin/sh\nsleep 10 &\nsleep 10\n"
+	if err := os.WriteFile(filepath.Join(dir, "gen.sh"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	e := &Exec{
+		Cmd:     []string{"./gen.sh"},
+		Dir:     dir,
+		Timeout: 100 * time.Millisecond,
+	}
+
+	start := time.Now()
+	_, err := e.Generate()
+	if want := "exec ./gen.sh: timed out after 100ms"; err == nil || err.Error() != want {
+		t.Errorf("Generate() error = %v, expected %q", err, want)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("Generate() returned after %s, expected soon after the timeout", elapsed)
+	}
+}
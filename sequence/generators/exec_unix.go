@@ -0,0 +1,17 @@
+//go:build unix
+
+package generators
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killGroup runs the program in its own process group, and kills the whole
+// group when it's cancelled, so programs it started don't outlive it
+func killGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
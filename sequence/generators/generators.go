@@ -1,6 +1,10 @@
 package generators
 
 import (
+	"slices"
+	"strconv"
+	"strings"
+
 	metaparser "github.com/odaacabeef/beefdown/sequence/parsers/metadata"
 )
 
@@ -9,21 +13,71 @@ type Generator interface {
 	Generate() ([]string, error) // Generates and returns step strings
 }
 
+// Parts looks up the steps of a part defined earlier in the sequence. Each
+// step is returned as its note text with multiplication already expanded.
+type Parts func(name string) ([]string, bool)
+
+// Env describes the sequence a generator is defined in
+type Env struct {
+	Dir   string     // Directory of the sequence file
+	Parts Parts      // Parts defined before the generator
+	Steps StepParser // Converts step text to events for the generated part
+}
+
 // Factory creates a Generator from metadata and parameters
-type Factory func(meta metaparser.PartMetadata, params map[string]interface{}) (Generator, error)
+type Factory func(meta metaparser.PartMetadata, params map[string]interface{}, env Env) (Generator, error)
+
+// Spec describes a generator type and its parameters
+type Spec struct {
+	Name        string
+	Description string
+	Params      []Param
+	AnyParams   bool // Accept parameters that aren't in Params
+	Factory     Factory
+}
 
 // Global registry of generator types
-var registry = make(map[string]Factory)
+var registry = make(map[string]Spec)
 
-// Register registers a generator type with the given factory
-func Register(name string, factory Factory) {
-	registry[name] = factory
+// Register registers a generator type
+func Register(spec Spec) {
+	registry[spec.Name] = spec
 }
 
-// Get retrieves a generator factory by name
+// Get retrieves a generator factory by name. Parameters that aren't set are
+// passed to the factory with their defaults.
 func Get(name string) (Factory, bool) {
-	factory, ok := registry[name]
-	return factory, ok
+	spec, ok := registry[name]
+	if !ok {
+		return nil, false
+	}
+	return func(meta metaparser.PartMetadata, params map[string]interface{}, env Env) (Generator, error) {
+		return spec.Factory(meta, spec.withDefaults(params), env)
+	}, true
+}
+
+// GetSpec retrieves a generator type by name
+func GetSpec(name string) (Spec, bool) {
+	spec, ok := registry[name]
+	return spec, ok
+}
+
+// Seeded reports whether the generator accepts a seed parameter
+func (s Spec) Seeded() bool {
+	_, ok := s.param("seed")
+	return ok || s.AnyParams
+}
+
+// Specs returns every registered generator type, sorted by name
+func Specs() []Spec {
+	var specs []Spec
+	for _, spec := range registry {
+		specs = append(specs, spec)
+	}
+	slices.SortFunc(specs, func(a, b Spec) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return specs
 }
 
 // Helper functions for extracting typed parameters from generic map
@@ -54,3 +108,33 @@ func getFloatParam(params map[string]interface{}, key string) (float64, bool) {
 	}
 	return 0, false
 }
+
+func getBoolParam(params map[string]interface{}, key string) (bool, bool) {
+	if val, ok := params[key]; ok {
+		if node, ok := val.(*metaparser.BooleanNode); ok {
+			return node.Value, true
+		}
+	}
+	return false, false
+}
+
+// getIntListParam reads a single number (e.g. "2") or a comma-separated list
+// of numbers (e.g. "1,1,2,4")
+func getIntListParam(params map[string]interface{}, key string) ([]int, bool) {
+	if n, ok := getIntParam(params, key); ok {
+		return []int{n}, true
+	}
+	s, ok := getStringParam(params, key)
+	if !ok {
+		return nil, false
+	}
+	var values []int
+	for _, f := range strings.Split(s, ",") {
+		v, err := strconv.Atoi(strings.TrimSpace(f))
+		if err != nil {
+			return nil, false
+		}
+		values = append(values, v)
+	}
+	return values, true
+}
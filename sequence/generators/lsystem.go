@@ -0,0 +1,139 @@
+package generators
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	metaparser "github.com/odaacabeef/beefdown/sequence/parsers/metadata"
+)
+
+// maxLSystemSymbols limits how large an L-system can grow
+const maxLSystemSymbols = 65536
+
+// LSystem generates steps by rewriting an axiom with a set of rules
+// Each symbol of the result is one step. Symbols mapped to notes are hits and
+// all other symbols are rests. Rules with alternatives (A=AB|BA) pick one at
+// random for each symbol.
+type LSystem struct {
+	Axiom      string
+	Rules      map[rune][]string
+	Iterations int
+	Notes      map[rune]string
+	Length     int // Maximum number of steps; 0 for no limit
+	Seed       int64
+}
+
+func (l *LSystem) Generate() ([]string, error) {
+	if l.Axiom == "" {
+		return nil, fmt.Errorf("lsystem: axiom is required")
+	}
+	if l.Iterations < 0 {
+		return nil, fmt.Errorf("lsystem: iterations must be non-negative")
+	}
+
+	rng := rand.New(rand.NewSource(l.Seed))
+
+	symbols := []rune(l.Axiom)
+	for range l.Iterations {
+		var next []rune
+		for _, s := range symbols {
+			alternatives, ok := l.Rules[s]
+			if !ok {
+				next = append(next, s)
+				continue
+			}
+			next = append(next, []rune(alternatives[rng.Intn(len(alternatives))])...)
+		}
+		if len(next) > maxLSystemSymbols {
+			return nil, fmt.Errorf("lsystem: more than %d symbols, reduce iterations", maxLSystemSymbols)
+		}
+		symbols = next
+	}
+
+	if l.Length > 0 && len(symbols) > l.Length {
+		symbols = symbols[:l.Length]
+	}
+
+	var steps []string
+	for _, s := range symbols {
+		if note, ok := l.Notes[s]; ok {
+			steps = append(steps, fmt.Sprintf("%s:1", note))
+		} else {
+			steps = append(steps, "") // rest
+		}
+	}
+
+	return steps, nil
+}
+
+// parseSymbolMap parses comma-separated symbol=value pairs (e.g. "A=c4,B=e4")
+func parseSymbolMap(s string) (map[rune]string, error) {
+	m := map[rune]string{}
+	for _, pair := range strings.Split(s, ",") {
+		symbol, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || len([]rune(symbol)) != 1 {
+			return nil, fmt.Errorf("invalid pair %q, expected symbol=value", pair)
+		}
+		m[[]rune(symbol)[0]] = value
+	}
+	return m, nil
+}
+
+func newLSystem(meta metaparser.PartMetadata, params map[string]interface{}, env Env) (Generator, error) {
+	axiom, ok := getStringParam(params, "axiom")
+	if !ok {
+		return nil, fmt.Errorf("lsystem: missing required parameter 'axiom'")
+	}
+
+	rules, ok := getStringParam(params, "rules")
+	if !ok {
+		return nil, fmt.Errorf("lsystem: missing required parameter 'rules'")
+	}
+	ruleMap, err := parseSymbolMap(rules)
+	if err != nil {
+		return nil, fmt.Errorf("lsystem: rules: %w", err)
+	}
+	alternatives := map[rune][]string{}
+	for symbol, rewrite := range ruleMap {
+		alternatives[symbol] = strings.Split(rewrite, "|")
+	}
+
+	notes, ok := getStringParam(params, "notes")
+	if !ok {
+		return nil, fmt.Errorf("lsystem: missing required parameter 'notes'")
+	}
+	noteMap, err := parseSymbolMap(notes)
+	if err != nil {
+		return nil, fmt.Errorf("lsystem: notes: %w", err)
+	}
+
+	iterations, _ := getIntParam(params, "iterations")
+	length, _ := getIntParam(params, "length")
+	seed, _ := getIntParam(params, "seed")
+
+	return &LSystem{
+		Axiom:      axiom,
+		Rules:      alternatives,
+		Iterations: iterations,
+		Notes:      noteMap,
+		Length:     length,
+		Seed:       int64(seed),
+	}, nil
+}
+
+func init() {
+	Register(Spec{
+		Name:        "lsystem",
+		Description: "Rewrites an axiom with rules and plays each symbol as a step",
+		Params: []Param{
+			{Name: "axiom", Type: StringParam, Required: true, Description: "Starting symbols"},
+			{Name: "rules", Type: StringParam, Required: true, Description: "Rewrite rules (e.g. A=AB|BA,B=A)"},
+			{Name: "notes", Type: StringParam, Required: true, Description: "Notes for symbols (e.g. A=c4,B=e4)"},
+			{Name: "iterations", Type: IntParam, Default: "1", Min: bound(0), Description: "Number of rewrites"},
+			{Name: "length", Type: IntParam, Default: "0", Min: bound(0), Description: "Maximum number of steps; 0 for no limit"},
+			{Name: "seed", Type: IntParam, Default: "0", Description: "Random seed"},
+		},
+		Factory: newLSystem,
+	})
+}
@@ -0,0 +1,146 @@
+package generators
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLSystem(t *testing.T) {
+	notes := map[rune]string{'A': "c4", 'B': "e4"}
+	tests := []struct {
+		name     string
+		lsystem  LSystem
+		expected []string
+		err      string
+	}{
+		{
+			// A -> AB -> ABA
+			name:     "two iterations",
+			lsystem:  LSystem{Axiom: "A", Rules: map[rune][]string{'A': {"AB"}, 'B': {"A"}}, Iterations: 2, Notes: notes},
+			expected: []string{"c4:1", "e4:1", "c4:1"},
+		},
+		{
+			// A -> AB -> ABA -> ABAAB
+			name:     "three iterations",
+			lsystem:  LSystem{Axiom: "A", Rules: map[rune][]string{'A': {"AB"}, 'B': {"A"}}, Iterations: 3, Notes: notes},
+			expected: []string{"c4:1", "e4:1", "c4:1", "c4:1", "e4:1"},
+		},
+		{
+			name:     "no iterations",
+			lsystem:  LSystem{Axiom: "AB", Rules: map[rune][]string{'A': {"BB"}}, Notes: notes},
+			expected: []string{"c4:1", "e4:1"},
+		},
+		{
+			// Symbols without rules stay, and symbols without notes are rests
+			name:     "constants and rests",
+			lsystem:  LSystem{Axiom: "A-", Rules: map[rune][]string{'A': {"A-B"}}, Iterations: 1, Notes: notes},
+			expected: []string{"c4:1", "", "e4:1", ""},
+		},
+		{
+			name:     "length",
+			lsystem:  LSystem{Axiom: "A", Rules: map[rune][]string{'A': {"AB"}, 'B': {"A"}}, Iterations: 3, Notes: notes, Length: 2},
+			expected: []string{"c4:1", "e4:1"},
+		},
+		{
+			// 2^16 symbols is the limit
+			name:    "limit",
+			lsystem: LSystem{Axiom: "A", Rules: map[rune][]string{'A': {"AA"}}, Iterations: 17, Notes: notes},
+			err:     fmt.Sprintf("lsystem: more than %d symbols, reduce iterations", maxLSystemSymbols),
+		},
+		{
+			name:    "no axiom",
+			lsystem: LSystem{Rules: map[rune][]string{'A': {"AB"}}, Iterations: 1, Notes: notes},
+			err:     "lsystem: axiom is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			steps, err := tt.lsystem.Generate()
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Errorf("Generate() error = %v, expected %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Generate() unexpected error: %v", err)
+			}
+			if !slices.Equal(steps, tt.expected) {
+				t.Errorf("Generate() = %q, expected %q", steps, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLSystemLimit(t *testing.T) {
+	l := LSystem{Axiom: "A", Rules: map[rune][]string{'A': {"AA"}}, Iterations: 16, Notes: map[rune]string{'A': "c4"}}
+	steps, err := l.Generate()
+	if err != nil {
+		t.Fatalf("Generate() unexpected error: %v", err)
+	}
+	if len(steps) != maxLSystemSymbols {
+		t.Errorf("Generate() returned %d steps, expected %d", len(steps), maxLSystemSymbols)
+	}
+}
+
+func TestLSystemAlternatives(t *testing.T) {
+	generate := func(seed int64) string {
+		l := LSystem{
+			Axiom:      "A",
+			Rules:      map[rune][]string{'A': {"AB", "BA"}, 'B': {"A", "BB"}},
+			Iterations: 6,
+			Notes:      map[rune]string{'A': "c4", 'B': "e4"},
+			Seed:       seed,
+		}
+		steps, err := l.Generate()
+		if err != nil {
+			t.Fatalf("Generate() unexpected error: %v", err)
+		}
+		return strings.Join(steps, "|")
+	}
+	if generate(1) != generate(1) {
+		t.Error("Generate() with the same seed returned different steps")
+	}
+	if generate(1) == generate(2) {
+		t.Error("Generate() with a different seed returned the same steps")
+	}
+}
+
+func TestParseSymbolMap(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected map[rune]string
+		err      string
+	}{
+		{input: "A=AB|BA,B=A", expected: map[rune]string{'A': "AB|BA", 'B': "A"}},
+		{input: "A=c4, B=e4", expected: map[rune]string{'A': "c4", 'B': "e4"}},
+		{input: "A", err: `invalid pair "A", expected symbol=value`},
+		{input: "AB=c4", err: `invalid pair "AB=c4", expected symbol=value`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			m, err := parseSymbolMap(tt.input)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Errorf("parseSymbolMap() error = %v, expected %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSymbolMap() unexpected error: %v", err)
+			}
+			if len(m) != len(tt.expected) {
+				t.Fatalf("parseSymbolMap() = %v, expected %v", m, tt.expected)
+			}
+			for k, v := range tt.expected {
+				if m[k] != v {
+					t.Errorf("parseSymbolMap()[%c] = %q, expected %q", k, m[k], v)
+				}
+			}
+		})
+	}
+}
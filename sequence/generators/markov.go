@@ -0,0 +1,123 @@
+package generators
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	metaparser "github.com/odaacabeef/beefdown/sequence/parsers/metadata"
+)
+
+// Markov generates steps from a Markov chain trained on existing parts
+// Each state is a complete step (pitch and duration), so the output keeps the
+// note lengths and rests of the source material
+type Markov struct {
+	Sources [][]string // Steps of each source part
+	Length  int
+	Order   int
+	Seed    int64
+}
+
+func (m *Markov) Generate() ([]string, error) {
+	if m.Order < 1 {
+		return nil, fmt.Errorf("markov: order must be at least 1")
+	}
+	if m.Length < 0 {
+		return nil, fmt.Errorf("markov: length must be non-negative")
+	}
+
+	// Build the transition table. Sources are treated as loops so every state
+	// has at least one successor.
+	transitions := map[string][]string{}
+	var starts [][]string
+	for _, src := range m.Sources {
+		if len(src) == 0 {
+			continue
+		}
+		for i := range src {
+			state := make([]string, m.Order)
+			for j := range m.Order {
+				state[j] = src[(i+j)%len(src)]
+			}
+			if i == 0 {
+				starts = append(starts, state)
+			}
+			key := markovKey(state)
+			transitions[key] = append(transitions[key], src[(i+m.Order)%len(src)])
+		}
+	}
+	if len(starts) == 0 {
+		return nil, fmt.Errorf("markov: source parts have no steps")
+	}
+
+	rng := rand.New(rand.NewSource(m.Seed))
+
+	// Start where the first source starts
+	state := append([]string{}, starts[0]...)
+	steps := append([]string{}, state...)
+
+	for len(steps) < m.Length {
+		next, ok := transitions[markovKey(state)]
+		if !ok {
+			// Dead end - restart from a random source
+			state = append([]string{}, starts[rng.Intn(len(starts))]...)
+			steps = append(steps, state...)
+			continue
+		}
+		n := next[rng.Intn(len(next))]
+		steps = append(steps, n)
+		state = append(state[1:], n)
+	}
+
+	return steps[:m.Length], nil
+}
+
+func markovKey(state []string) string {
+	return strings.Join(state, "\x00")
+}
+
+func newMarkov(meta metaparser.PartMetadata, params map[string]interface{}, env Env) (Generator, error) {
+	names, ok := getStringParam(params, "parts")
+	if !ok {
+		return nil, fmt.Errorf("markov: missing required parameter 'parts'")
+	}
+
+	var sources [][]string
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		steps, ok := env.Parts(name)
+		if !ok {
+			return nil, fmt.Errorf("markov: part %q not found", name)
+		}
+		sources = append(sources, steps)
+	}
+
+	length, ok := getIntParam(params, "length")
+	if !ok {
+		return nil, fmt.Errorf("markov: missing required parameter 'length'")
+	}
+
+	order, _ := getIntParam(params, "order")
+	seed, _ := getIntParam(params, "seed")
+
+	return &Markov{
+		Sources: sources,
+		Length:  length,
+		Order:   order,
+		Seed:    int64(seed),
+	}, nil
+}
+
+func init() {
+	Register(Spec{
+		Name:        "markov",
+		Description: "Generates steps from a Markov chain trained on existing parts",
+		Params: []Param{
+			{Name: "parts", Type: StringParam, Required: true, Description: "Comma-separated names of source parts"},
+			{Name: "length", Type: IntParam, Required: true, Min: bound(0), Description: "Number of steps"},
+			{Name: "order", Type: IntParam, Default: "1", Min: bound(1), Description: "Number of previous steps each choice depends on"},
+			{Name: "seed", Type: IntParam, Default: "0", Description: "Random seed"},
+		},
+		Factory: newMarkov,
+	})
+}
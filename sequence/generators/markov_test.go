@@ -0,0 +1,160 @@
+package generators
+
+import (
+	"slices"
+	"testing"
+
+	metaparser "github.com/odaacabeef/beefdown/sequence/parsers/metadata"
+)
+
+func TestMarkovSeed(t *testing.T) {
+	source := []string{"c4", "d4", "c4", "e4", "c4", "g4", "", "c4:2"}
+	generate := func(seed int64) []string {
+		m := &Markov{Sources: [][]string{source}, Length: 32, Order: 1, Seed: seed}
+		steps, err := m.Generate()
+		if err != nil {
+			t.Fatalf("Generate() unexpected error: %v", err)
+		}
+		return steps
+	}
+
+	first := generate(1)
+	if len(first) != 32 {
+		t.Fatalf("Generate() returned %d steps, want 32", len(first))
+	}
+	if !slices.Equal(generate(1), first) {
+		t.Error("Generate() with the same seed returned different steps")
+	}
+	if slices.Equal(generate(2), first) {
+		t.Error("Generate() with a different seed returned the same steps")
+	}
+	for _, step := range first {
+		if !slices.Contains(source, step) {
+			t.Errorf("Generate() returned step %q, which isn't in the source", step)
+		}
+	}
+}
+
+func TestMarkovOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		sources  [][]string
+		length   int
+		order    int
+		expected []string
+	}{
+		{
+			// Every pair of steps has one successor, so the source loops
+			name:     "order 2 follows the source",
+			sources:  [][]string{{"a", "b", "a", "c"}},
+			length:   10,
+			order:    2,
+			expected: []string{"a", "b", "a", "c", "a", "b", "a", "c", "a", "b"},
+		},
+		{
+			name:     "shorter than order",
+			sources:  [][]string{{"a", "b", "a", "c"}},
+			length:   1,
+			order:    2,
+			expected: []string{"a"},
+		},
+		{
+			// Steps follow each other across the end of the source
+			name:     "source loops",
+			sources:  [][]string{{"a", "b", "c"}},
+			length:   7,
+			order:    1,
+			expected: []string{"a", "b", "c", "a", "b", "c", "a"},
+		},
+		{
+			// Empty sources are skipped and output starts with the first
+			// source that has steps
+			name:     "empty source",
+			sources:  [][]string{{}, {"x", "y"}},
+			length:   4,
+			order:    1,
+			expected: []string{"x", "y", "x", "y"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for seed := range int64(5) {
+				m := &Markov{Sources: tt.sources, Length: tt.length, Order: tt.order, Seed: seed}
+				steps, err := m.Generate()
+				if err != nil {
+					t.Fatalf("Generate() unexpected error: %v", err)
+				}
+				if !slices.Equal(steps, tt.expected) {
+					t.Errorf("Generate() with seed %d = %v, expected %v", seed, steps, tt.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestMarkovErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		markov   Markov
+		expected string
+	}{
+		{
+			name:     "order",
+			markov:   Markov{Sources: [][]string{{"a"}}, Length: 4},
+			expected: "markov: order must be at least 1",
+		},
+		{
+			name:     "no steps",
+			markov:   Markov{Sources: [][]string{{}}, Length: 4, Order: 1},
+			expected: "markov: source parts have no steps",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.markov.Generate()
+			if err == nil || err.Error() != tt.expected {
+				t.Errorf("Generate() error = %v, expected %q", err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNewMarkov(t *testing.T) {
+	parts := map[string][]string{
+		"bass": {"c2:2", "", "g2", "c2?50"},
+	}
+	env := Env{
+		Parts: func(name string) ([]string, bool) {
+			steps, ok := parts[name]
+			return steps, ok
+		},
+	}
+
+	meta, err := metaparser.ParseFuncMetadata(".gen.markov\nparts:bass length:4 order:4")
+	if err != nil {
+		t.Fatalf("ParseFuncMetadata() error = %v", err)
+	}
+	gen, err := newMarkov(meta.PartMetadata, meta.Params, env)
+	if err != nil {
+		t.Fatalf("newMarkov() unexpected error: %v", err)
+	}
+	steps, err := gen.Generate()
+	if err != nil {
+		t.Fatalf("Generate() unexpected error: %v", err)
+	}
+	// Step text is used as it is, including durations, rests and conditions
+	if !slices.Equal(steps, parts["bass"]) {
+		t.Errorf("Generate() = %q, expected %q", steps, parts["bass"])
+	}
+
+	meta, err = metaparser.ParseFuncMetadata(".gen.markov\nparts:bass,lead length:4")
+	if err != nil {
+		t.Fatalf("ParseFuncMetadata() error = %v", err)
+	}
+	_, err = newMarkov(meta.PartMetadata, meta.Params, env)
+	if expected := `markov: part "lead" not found`; err == nil || err.Error() != expected {
+		t.Errorf("newMarkov() error = %v, expected %q", err, expected)
+	}
+}
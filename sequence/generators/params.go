@@ -0,0 +1,249 @@
+package generators
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+
+	metaparser "github.com/odaacabeef/beefdown/sequence/parsers/metadata"
+)
+
+// ParamType is the type of value a generator parameter accepts
+type ParamType int
+
+const (
+	StringParam  ParamType = iota
+	IntParam               // Whole number
+	NumberParam            // Any number
+	BoolParam              // true or false
+	IntListParam           // Whole number or comma-separated whole numbers
+)
+
+func (t ParamType) String() string {
+	switch t {
+	case StringParam:
+		return "string"
+	case IntParam:
+		return "int"
+	case NumberParam:
+		return "number"
+	case BoolParam:
+		return "bool"
+	case IntListParam:
+		return "int list"
+	}
+	return "unknown"
+}
+
+// Param describes a generator parameter
+type Param struct {
+	Name        string
+	Type        ParamType
+	Required    bool
+	Default     string   // Value used when the parameter isn't set, if any
+	Min         *float64 // Optional lower bound for numeric values
+	Max         *float64 // Optional upper bound for numeric values
+	Description string
+}
+
+// bound returns a pointer to v for Param.Min and Param.Max
+func bound(v float64) *float64 {
+	return &v
+}
+
+// Range describes the bounds of a numeric parameter (e.g. "0-255", ">= 1")
+func (p Param) Range() string {
+	switch {
+	case p.Min != nil && p.Max != nil:
+		return fmt.Sprintf("%g-%g", *p.Min, *p.Max)
+	case p.Min != nil:
+		return fmt.Sprintf(">= %g", *p.Min)
+	case p.Max != nil:
+		return fmt.Sprintf("<= %g", *p.Max)
+	}
+	return ""
+}
+
+// partParams are metadata keys every generated part accepts. seed is only
+// accepted by generators with a seed parameter.
+var partParams = []Param{
+	{Name: "name", Type: StringParam},
+	{Name: "group", Type: StringParam},
+	{Name: "ch", Type: IntParam, Min: bound(1), Max: bound(16)},
+	{Name: "div", Type: StringParam},
+	{Name: "map", Type: StringParam},
+	{Name: "loop", Type: StringParam},
+	{Name: "delay", Type: IntParam},
+	{Name: "mode", Type: StringParam},
+	{Name: "overlap", Type: IntParam, Min: bound(0)},
+}
+
+// parsedKeys are part keys that take a number or a word (e.g. regen:loop or
+// regen:2), which are checked when the metadata is parsed
+var parsedKeys = []string{"regen", "strum"}
+
+// Validate checks params against the named generator's spec. Unknown keys,
+// values of the wrong type, values out of range, and missing required
+// parameters are errors.
+func Validate(name string, params map[string]interface{}) error {
+	spec, ok := registry[name]
+	if !ok {
+		return fmt.Errorf("unknown generator type: %s", name)
+	}
+
+	// Sorted so the first error reported doesn't depend on map order
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		p, ok := spec.param(key)
+		if !ok {
+			p, ok = partParam(key)
+		}
+		if !ok {
+			if spec.AnyParams || slices.Contains(parsedKeys, key) {
+				continue
+			}
+			if s := spec.suggest(key); s != "" {
+				return fmt.Errorf("%s: unknown parameter '%s' (did you mean '%s'?)", name, key, s)
+			}
+			return fmt.Errorf("%s: unknown parameter '%s'", name, key)
+		}
+		if err := p.check(params[key]); err != nil {
+			return fmt.Errorf("%s: %s %w", name, key, err)
+		}
+	}
+
+	for _, p := range spec.Params {
+		if _, ok := params[p.Name]; p.Required && !ok {
+			return fmt.Errorf("%s: missing required parameter '%s'", name, p.Name)
+		}
+	}
+
+	return nil
+}
+
+// param looks up a parameter by name
+func (s Spec) param(name string) (Param, bool) {
+	for _, p := range s.Params {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Param{}, false
+}
+
+// partParam looks up a part key by name
+func partParam(name string) (Param, bool) {
+	i := slices.IndexFunc(partParams, func(p Param) bool { return p.Name == name })
+	if i < 0 {
+		return Param{}, false
+	}
+	return partParams[i], true
+}
+
+// withDefaults returns a copy of params with the default of each parameter
+// that isn't set
+func (s Spec) withDefaults(params map[string]interface{}) map[string]interface{} {
+	out := maps.Clone(params)
+	if out == nil {
+		out = map[string]interface{}{}
+	}
+	for _, p := range s.Params {
+		if _, ok := out[p.Name]; !ok && p.Default != "" {
+			out[p.Name] = p.defaultValue()
+		}
+	}
+	return out
+}
+
+// defaultValue returns the parameter's default as a parsed metadata value
+func (p Param) defaultValue() interface{} {
+	switch p.Type {
+	case IntParam, NumberParam, IntListParam:
+		if n, err := strconv.ParseFloat(p.Default, 64); err == nil {
+			return &metaparser.NumberNode{Value: n}
+		}
+	case BoolParam:
+		return &metaparser.BooleanNode{Value: p.Default == "true"}
+	}
+	return &metaparser.StringNode{Value: p.Default}
+}
+
+// suggest returns the parameter name closest to a misspelled key, if any is
+// close enough to be a likely typo
+func (s Spec) suggest(key string) string {
+	best, bestDist := "", 3
+	for _, p := range s.Params {
+		if d := editDistance(key, p.Name); d < bestDist {
+			best, bestDist = p.Name, d
+		}
+	}
+	return best
+}
+
+// check verifies a parsed metadata value has the parameter's type and range
+func (p Param) check(value interface{}) error {
+	var numbers []float64
+
+	switch p.Type {
+	case StringParam:
+		if _, ok := value.(*metaparser.StringNode); !ok {
+			return fmt.Errorf("must be a string")
+		}
+	case IntParam, NumberParam:
+		n, ok := value.(*metaparser.NumberNode)
+		if !ok {
+			return fmt.Errorf("must be a number")
+		}
+		if p.Type == IntParam && n.Value != math.Trunc(n.Value) {
+			return fmt.Errorf("must be a whole number")
+		}
+		numbers = []float64{n.Value}
+	case BoolParam:
+		if _, ok := value.(*metaparser.BooleanNode); !ok {
+			return fmt.Errorf("must be true or false")
+		}
+	case IntListParam:
+		list, ok := getIntListParam(map[string]interface{}{p.Name: value}, p.Name)
+		if !ok {
+			return fmt.Errorf("must be a comma-separated list of whole numbers")
+		}
+		for _, v := range list {
+			numbers = append(numbers, float64(v))
+		}
+	}
+
+	for _, n := range numbers {
+		if (p.Min != nil && n < *p.Min) || (p.Max != nil && n > *p.Max) {
+			return fmt.Errorf("must be %s", p.Range())
+		}
+	}
+	return nil
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
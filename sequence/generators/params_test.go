@@ -0,0 +1,132 @@
+package generators
+
+import (
+	"slices"
+	"testing"
+
+	metaparser "github.com/odaacabeef/beefdown/sequence/parsers/metadata"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string // Empty for no error
+	}{
+		{
+			name:  "valid",
+			input: ".gen.walk\nname:lead ch:2\nlength:16 scale:minor durations:1,2 velocity:90",
+		},
+		{
+			name:  "part keys",
+			input: ".gen.markov\nname:m parts:a length:8 delay:2 strum:2 mode:mono overlap:1",
+		},
+		{
+			name:     "misspelled",
+			input:    ".gen.walk\nlenght:16",
+			expected: "walk: unknown parameter 'lenght' (did you mean 'length'?)",
+		},
+		{
+			name:     "unknown",
+			input:    ".gen.arpeggiate\nnotes:c4 tempo:3",
+			expected: "arpeggiate: unknown parameter 'tempo'",
+		},
+		{
+			name:     "wrong type",
+			input:    ".gen.arpeggiate\nnotes:c4 length:long",
+			expected: "arpeggiate: length must be a number",
+		},
+		{
+			name:     "not whole",
+			input:    ".gen.arpeggiate\nnotes:c4 length:1.5",
+			expected: "arpeggiate: length must be a whole number",
+		},
+		{
+			name:     "out of range",
+			input:    ".gen.automaton\nrule:300 generations:4 notes:c4",
+			expected: "automaton: rule must be 0-255",
+		},
+		{
+			name:     "list out of range",
+			input:    ".gen.progression\nchords:I,V beats:4,0",
+			expected: "progression: beats must be >= 1",
+		},
+		{
+			name:     "missing",
+			input:    ".gen.euclidean\npulses:3 note:c4",
+			expected: "euclidean: missing required parameter 'steps'",
+		},
+		{
+			name:     "seed without a seed parameter",
+			input:    ".gen.arpeggiate\nnotes:c4 seed:3",
+			expected: "arpeggiate: unknown parameter 'seed'",
+		},
+		{
+			name:     "seed wrong type",
+			input:    ".gen.walk\nlength:4 seed:foo",
+			expected: "walk: seed must be a number",
+		},
+		{
+			name:     "part key wrong type",
+			input:    ".gen.markov\nparts:a length:8 delay:abc",
+			expected: "markov: delay must be a number",
+		},
+		{
+			name:     "part key out of range",
+			input:    ".gen.arpeggiate\nnotes:c4 ch:17",
+			expected: "arpeggiate: ch must be 1-16",
+		},
+		{
+			name:  "any params",
+			input: ".gen.exec\ncmd:'./gen.py' density:0.5",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, err := metaparser.ParseFuncMetadata(tt.input)
+			if err != nil {
+				t.Fatalf("ParseFuncMetadata() error = %v", err)
+			}
+			err = Validate(meta.FuncType, meta.Params)
+			if tt.expected == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.expected {
+				t.Errorf("Validate() error = %v, expected %q", err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	// Defaults are values the parameters accept
+	for _, spec := range Specs() {
+		for _, p := range spec.Params {
+			if p.Default == "" {
+				continue
+			}
+			if err := p.check(p.defaultValue()); err != nil {
+				t.Errorf("%s: default %s of %s %v", spec.Name, p.Default, p.Name, err)
+			}
+		}
+	}
+
+	// and are passed to factories for parameters that aren't set
+	factory, _ := Get("walk")
+	meta, err := metaparser.ParseFuncMetadata(".gen.walk\nlength:4 up:0")
+	if err != nil {
+		t.Fatalf("ParseFuncMetadata() error = %v", err)
+	}
+	gen, err := factory(meta.PartMetadata, meta.Params, Env{})
+	if err != nil {
+		t.Fatalf("factory() unexpected error: %v", err)
+	}
+	w := gen.(*Walk)
+	if w.Up != 0 || w.Down != 35 || w.Key != "c" || !slices.Equal(w.Durations, []int{1}) || w.Velocity != 100 {
+		t.Errorf("factory() = %+v, expected up 0 and other parameters' defaults", w)
+	}
+}
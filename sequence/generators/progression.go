@@ -0,0 +1,256 @@
+package generators
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/odaacabeef/beefdown/music"
+	metaparser "github.com/odaacabeef/beefdown/sequence/parsers/metadata"
+)
+
+// Progression generates a chord part from Roman numerals (e.g. i,iv,VII,III)
+// Uppercase numerals are major and lowercase are minor. Numerals may be
+// prefixed with b or # to borrow chords from outside the key, followed by a
+// quality suffix (7, M7, o, ø, +, sus4, ...) and a secondary target (V/V).
+type Progression struct {
+	Key       string
+	Scale     string
+	Chords    []string
+	Beats     []int // Steps per chord, cycled if shorter than Chords
+	VoiceLead bool
+}
+
+// Chord qualities for numeral suffixes, by numeral case
+var (
+	upperQualities = map[string]string{
+		"": "M", "7": "7", "M7": "M7", "maj7": "M7", "9": "9", "M9": "M9",
+		"6": "6", "69": "69", "11": "11", "13": "13", "add9": "add9",
+		"+": "aug", "aug": "aug", "+7": "aug7", "aug7": "aug7",
+		"sus": "sus4", "sus2": "sus2", "sus4": "sus4", "7sus4": "7sus4",
+		"5": "5",
+	}
+	lowerQualities = map[string]string{
+		"": "m", "7": "m7", "M7": "mM7", "9": "m9", "6": "m6", "11": "m11",
+		"add9": "madd9", "o": "dim", "°": "dim", "dim": "dim",
+		"o7": "dim7", "°7": "dim7", "dim7": "dim7",
+		"ø": "m7b5", "ø7": "m7b5", "m7b5": "m7b5",
+	}
+)
+
+// Chord root names as used in parts
+var chordRoots = []string{"C", "Db", "D", "Eb", "E", "F", "Gb", "G", "Ab", "A", "Bb", "B"}
+
+func (p *Progression) Generate() ([]string, error) {
+	if len(p.Chords) == 0 {
+		return nil, fmt.Errorf("progression: no chords provided")
+	}
+	if len(p.Beats) == 0 {
+		return nil, fmt.Errorf("progression: beats are required")
+	}
+	for _, b := range p.Beats {
+		if b < 1 {
+			return nil, fmt.Errorf("progression: beats must be at least 1")
+		}
+	}
+
+	tonic, err := music.PitchClass(p.Key)
+	if err != nil {
+		return nil, fmt.Errorf("progression: %w", err)
+	}
+	intervals, err := music.ScaleIntervals(p.Scale)
+	if err != nil {
+		return nil, fmt.Errorf("progression: %w", err)
+	}
+	if len(intervals) != 7 {
+		return nil, fmt.Errorf("progression: scale %s does not have 7 degrees", p.Scale)
+	}
+
+	var steps []string
+	var prev []uint8
+	for i, numeral := range p.Chords {
+		root, quality, err := parseNumeral(numeral, tonic, intervals)
+		if err != nil {
+			return nil, fmt.Errorf("progression: %w", err)
+		}
+		beats := p.Beats[i%len(p.Beats)]
+
+		if !p.VoiceLead {
+			steps = append(steps, fmt.Sprintf("%s%s:%d", chordRoots[root], quality, beats))
+		} else {
+			notes := music.Chord(chordRoots[root], quality)
+			if prev != nil {
+				notes = closestVoicing(prev, notes)
+			}
+			prev = notes
+
+			var names []string
+			for _, n := range notes {
+				name, err := music.NoteName(n)
+				if err != nil {
+					return nil, fmt.Errorf("progression: %w", err)
+				}
+				names = append(names, fmt.Sprintf("%s:%d", name, beats))
+			}
+			steps = append(steps, strings.Join(names, " "))
+		}
+
+		for range beats - 1 {
+			steps = append(steps, "")
+		}
+	}
+
+	return steps, nil
+}
+
+// parseNumeral returns the root pitch class and chord quality of a Roman
+// numeral. Secondary chords (V/V) are built on the major scale of their target.
+func parseNumeral(numeral string, tonic uint8, intervals []uint8) (uint8, string, error) {
+	primary, target, secondary := strings.Cut(numeral, "/")
+	if secondary {
+		root, _, err := parseNumeral(target, tonic, intervals)
+		if err != nil {
+			return 0, "", err
+		}
+		tonic = root
+		intervals, _ = music.ScaleIntervals("major")
+	}
+
+	s := primary
+	accidental := 0
+	switch {
+	case strings.HasPrefix(s, "b"):
+		accidental = -1
+		s = s[1:]
+	case strings.HasPrefix(s, "#"):
+		accidental = 1
+		s = s[1:]
+	}
+
+	// Longest numerals first so "VII" isn't read as "V"
+	var degree int
+	var upper bool
+	for _, n := range []string{"VII", "III", "IV", "VI", "II", "V", "I"} {
+		switch {
+		case strings.HasPrefix(s, n):
+			upper = true
+		case strings.HasPrefix(s, strings.ToLower(n)):
+			upper = false
+		default:
+			continue
+		}
+		degree = slices.Index([]string{"I", "II", "III", "IV", "V", "VI", "VII"}, n) + 1
+		s = s[len(n):]
+		break
+	}
+	if degree == 0 {
+		return 0, "", fmt.Errorf("invalid numeral: %s", numeral)
+	}
+
+	qualities := lowerQualities
+	if upper {
+		qualities = upperQualities
+	}
+	quality, ok := qualities[s]
+	if !ok {
+		return 0, "", fmt.Errorf("invalid numeral quality: %s", numeral)
+	}
+
+	root := (int(tonic) + int(intervals[degree-1]) + accidental + 12) % 12
+	return uint8(root), quality, nil
+}
+
+// closestVoicing returns the inversion and octave of notes that moves the
+// least from prev
+func closestVoicing(prev, notes []uint8) []uint8 {
+	var best []uint8
+	bestCost := -1
+	voicing := slices.Clone(notes)
+	for range notes {
+		for _, shift := range []int{-12, 0, 12} {
+			candidate := make([]uint8, 0, len(voicing))
+			for _, n := range voicing {
+				shifted := int(n) + shift
+				if shifted < 12 || shifted > 127 {
+					candidate = nil
+					break
+				}
+				candidate = append(candidate, uint8(shifted))
+			}
+			if candidate == nil {
+				continue
+			}
+			cost := voiceDistance(prev, candidate) + voiceDistance(candidate, prev)
+			if bestCost < 0 || cost < bestCost {
+				best, bestCost = candidate, cost
+			}
+		}
+		// Next inversion: move the lowest note up an octave
+		voicing = append(voicing[1:], voicing[0]+12)
+	}
+	if best == nil {
+		return notes
+	}
+	return best
+}
+
+// voiceDistance sums how far each note in a is from its nearest note in b
+func voiceDistance(a, b []uint8) int {
+	total := 0
+	for _, x := range a {
+		nearest := -1
+		for _, y := range b {
+			d := int(absDiff(x, y))
+			if nearest < 0 || d < nearest {
+				nearest = d
+			}
+		}
+		total += nearest
+	}
+	return total
+}
+
+func newProgression(meta metaparser.PartMetadata, params map[string]interface{}, env Env) (Generator, error) {
+	chords, ok := getStringParam(params, "chords")
+	if !ok {
+		return nil, fmt.Errorf("progression: missing required parameter 'chords'")
+	}
+
+	key, _ := getStringParam(params, "key")
+	scale, _ := getStringParam(params, "scale")
+
+	beats, ok := getIntListParam(params, "beats")
+	if !ok {
+		return nil, fmt.Errorf("progression: beats must be a comma-separated list of numbers")
+	}
+
+	voiceLead, _ := getBoolParam(params, "voicelead")
+
+	var numerals []string
+	for _, c := range strings.Split(chords, ",") {
+		numerals = append(numerals, strings.TrimSpace(c))
+	}
+
+	return &Progression{
+		Key:       key,
+		Scale:     scale,
+		Chords:    numerals,
+		Beats:     beats,
+		VoiceLead: voiceLead,
+	}, nil
+}
+
+func init() {
+	Register(Spec{
+		Name:        "progression",
+		Description: "Plays chords from Roman numerals in a key",
+		Params: []Param{
+			{Name: "chords", Type: StringParam, Required: true, Description: "Comma-separated numerals (e.g. I,vi,IV,V7)"},
+			{Name: "key", Type: StringParam, Default: "c", Description: "Root of the key"},
+			{Name: "scale", Type: StringParam, Default: "major", Description: "Seven note scale name"},
+			{Name: "beats", Type: IntListParam, Default: "4", Min: bound(1), Description: "Steps per chord, cycled"},
+			{Name: "voicelead", Type: BoolParam, Default: "false", Description: "Choose inversions close to the previous chord"},
+		},
+		Factory: newProgression,
+	})
+}
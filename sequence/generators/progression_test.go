@@ -0,0 +1,144 @@
+package generators
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/odaacabeef/beefdown/music"
+)
+
+func TestParseNumeral(t *testing.T) {
+	tests := []struct {
+		numeral  string
+		key      string
+		scale    string
+		root     uint8
+		quality  string
+		expected string // Error, if any
+	}{
+		{numeral: "I", key: "c", scale: "major", root: 0, quality: "M"},
+		{numeral: "ii7", key: "c", scale: "major", root: 2, quality: "m7"},
+		{numeral: "IV6", key: "c", scale: "major", root: 5, quality: "6"},
+		{numeral: "V7", key: "c", scale: "major", root: 7, quality: "7"},
+		{numeral: "VI", key: "c", scale: "major", root: 9, quality: "M"},
+		{numeral: "viio", key: "c", scale: "major", root: 11, quality: "dim"},
+		{numeral: "bVII", key: "c", scale: "major", root: 10, quality: "M"},
+		{numeral: "#ivø", key: "c", scale: "major", root: 6, quality: "m7b5"},
+		{numeral: "V/V", key: "c", scale: "major", root: 2, quality: "M"},
+		{numeral: "V7/ii", key: "c", scale: "major", root: 9, quality: "7"},
+		{numeral: "vii/ii", key: "c", scale: "major", root: 1, quality: "m"},
+		{numeral: "i", key: "a", scale: "minor", root: 9, quality: "m"},
+		{numeral: "III", key: "a", scale: "minor", root: 0, quality: "M"},
+		{numeral: "VII", key: "a", scale: "minor", root: 7, quality: "M"},
+		{numeral: "V", key: "a", scale: "minor", root: 4, quality: "M"},
+		{numeral: "X", key: "c", scale: "major", expected: "invalid numeral: X"},
+		{numeral: "iv7sus4", key: "c", scale: "major", expected: "invalid numeral quality: iv7sus4"},
+		{numeral: "V/Y", key: "c", scale: "major", expected: "invalid numeral: Y"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key+" "+tt.numeral, func(t *testing.T) {
+			tonic, err := music.PitchClass(tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			intervals, err := music.ScaleIntervals(tt.scale)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			root, quality, err := parseNumeral(tt.numeral, tonic, intervals)
+			if tt.expected != "" {
+				if err == nil || err.Error() != tt.expected {
+					t.Errorf("parseNumeral() error = %v, expected %q", err, tt.expected)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseNumeral() unexpected error: %v", err)
+			}
+			if root != tt.root || quality != tt.quality {
+				t.Errorf("parseNumeral() = %d %s, expected %d %s", root, quality, tt.root, tt.quality)
+			}
+		})
+	}
+}
+
+func TestClosestVoicing(t *testing.T) {
+	tests := []struct {
+		name     string
+		prev     []uint8
+		notes    []uint8
+		expected []uint8
+	}{
+		{
+			name:     "same chord",
+			prev:     []uint8{60, 64, 67},
+			notes:    []uint8{60, 64, 67},
+			expected: []uint8{60, 64, 67},
+		},
+		{
+			// C to F keeps c4 and moves the others up by a step or less
+			name:     "second inversion",
+			prev:     []uint8{60, 64, 67},
+			notes:    []uint8{65, 69, 72},
+			expected: []uint8{60, 65, 69},
+		},
+		{
+			// C to G keeps g4 and moves the others down a step
+			name:     "first inversion an octave down",
+			prev:     []uint8{60, 64, 67},
+			notes:    []uint8{67, 71, 74},
+			expected: []uint8{59, 62, 67},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := closestVoicing(tt.prev, tt.notes)
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("closestVoicing() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestProgression(t *testing.T) {
+	tests := []struct {
+		name     string
+		p        Progression
+		expected []string
+	}{
+		{
+			name: "chords",
+			p: Progression{
+				Key: "a", Scale: "minor", Chords: []string{"i", "iv", "V7"}, Beats: []int{2, 1},
+			},
+			expected: []string{"Am:2", "", "Dm:1", "E7:2", ""},
+		},
+		{
+			name: "voice leading",
+			p: Progression{
+				Key: "c", Scale: "major", Chords: []string{"I", "IV", "V", "I"}, Beats: []int{1}, VoiceLead: true,
+			},
+			expected: []string{
+				"c4:1 e4:1 g4:1",
+				"c4:1 f4:1 a4:1",
+				"b3:1 d4:1 g4:1",
+				"c4:1 e4:1 g4:1",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			steps, err := tt.p.Generate()
+			if err != nil {
+				t.Fatalf("Generate() unexpected error: %v", err)
+			}
+			if !slices.Equal(steps, tt.expected) {
+				t.Errorf("Generate() = %q, expected %q", steps, tt.expected)
+			}
+		})
+	}
+}
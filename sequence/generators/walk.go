@@ -0,0 +1,306 @@
+package generators
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/odaacabeef/beefdown/music"
+	metaparser "github.com/odaacabeef/beefdown/sequence/parsers/metadata"
+)
+
+// Walk generates a melodic line by randomly walking up and down a scale
+// Each move is weighted: up or down one scale degree, stay on the same note,
+// or leap two to four degrees in either direction. Velocities can vary
+// randomly around a base velocity.
+type Walk struct {
+	Key       string
+	Scale     string
+	Start     uint8
+	Low       uint8
+	High      uint8
+	Length    int
+	Up        float64
+	Down      float64
+	Stay      float64
+	Leap      float64
+	Rest      float64 // Probability (0-100) of a rest instead of a note
+	Durations []int   // Pool of note durations in steps
+	Velocity  int
+	Variation int // Maximum random change to Velocity, up or down
+	Div       int
+	Seed      int64
+}
+
+// walkNote is a note (or a rest when rest is true) chosen by the walk
+type walkNote struct {
+	note     uint8
+	duration int
+	velocity uint8
+	rest     bool
+}
+
+func (w *Walk) Generate() ([]string, error) {
+	notes, err := w.walk()
+	if err != nil {
+		return nil, err
+	}
+	return walkSteps(notes)
+}
+
+// walkSteps formats walked notes as step text
+func walkSteps(notes []walkNote) ([]string, error) {
+	var steps []string
+	for _, n := range notes {
+		if n.rest {
+			for range n.duration {
+				steps = append(steps, "")
+			}
+			continue
+		}
+		name, err := music.NoteName(n.note)
+		if err != nil {
+			return nil, fmt.Errorf("walk: %w", err)
+		}
+		steps = append(steps, fmt.Sprintf("%s:%d", name, n.duration))
+		for range n.duration - 1 {
+			steps = append(steps, "")
+		}
+	}
+	return steps, nil
+}
+
+// GenerateEvents generates the same line as Generate with velocity variation
+func (w *Walk) GenerateEvents() (Events, error) {
+	notes, err := w.walk()
+	if err != nil {
+		return Events{}, err
+	}
+	steps, err := walkSteps(notes)
+	if err != nil {
+		return Events{}, err
+	}
+
+	var events []Event
+	stepIdx := 0
+	for _, n := range notes {
+		if !n.rest {
+			events = append(events, Event{
+				Type:     NoteEvent,
+				Tick:     stepIdx * w.Div,
+				Note:     n.note,
+				Velocity: n.velocity,
+				Length:   n.duration * w.Div,
+			})
+		}
+		stepIdx += n.duration
+	}
+
+	return Events{
+		Steps:  len(steps),
+		Text:   steps,
+		Events: events,
+	}, nil
+}
+
+func (w *Walk) walk() ([]walkNote, error) {
+	if w.Length < 0 {
+		return nil, fmt.Errorf("walk: length must be non-negative")
+	}
+	if w.Low > w.High {
+		return nil, fmt.Errorf("walk: range low note is above high note")
+	}
+	if len(w.Durations) == 0 {
+		return nil, fmt.Errorf("walk: durations are required")
+	}
+	for _, d := range w.Durations {
+		if d < 1 {
+			return nil, fmt.Errorf("walk: durations must be at least 1")
+		}
+	}
+	total := w.Up + w.Down + w.Stay + w.Leap
+	if w.Up < 0 || w.Down < 0 || w.Stay < 0 || w.Leap < 0 || total <= 0 {
+		return nil, fmt.Errorf("walk: up, down, stay and leap must be non-negative and not all 0")
+	}
+	if w.Velocity < 1 || w.Velocity > 127 {
+		return nil, fmt.Errorf("walk: velocity must be between 1 and 127")
+	}
+	if w.Variation < 0 {
+		return nil, fmt.Errorf("walk: variation must be non-negative")
+	}
+
+	notes, err := music.ScaleNotes(w.Key, w.Scale, w.Low, w.High)
+	if err != nil {
+		return nil, fmt.Errorf("walk: %w", err)
+	}
+	if len(notes) == 0 {
+		return nil, fmt.Errorf("walk: no %s %s notes in range", w.Key, w.Scale)
+	}
+
+	// Begin on the scale note closest to the start note
+	idx := 0
+	for i, n := range notes {
+		if absDiff(n, w.Start) < absDiff(notes[idx], w.Start) {
+			idx = i
+		}
+	}
+
+	rng := rand.New(rand.NewSource(w.Seed))
+	// Velocities use their own source so they don't change the line
+	velRng := rand.New(rand.NewSource(w.Seed))
+
+	var walked []walkNote
+	length := 0
+	first := true
+	for length < w.Length {
+		duration := w.Durations[rng.Intn(len(w.Durations))]
+		duration = min(duration, w.Length-length)
+		length += duration
+
+		if rng.Float64()*100 < w.Rest {
+			walked = append(walked, walkNote{duration: duration, rest: true})
+			continue
+		}
+
+		if !first {
+			idx = w.move(rng, idx, len(notes), total)
+		}
+		first = false
+
+		velocity := w.Velocity
+		if w.Variation > 0 {
+			velocity += velRng.Intn(2*w.Variation+1) - w.Variation
+		}
+		walked = append(walked, walkNote{
+			note:     notes[idx],
+			duration: duration,
+			velocity: uint8(max(1, min(velocity, 127))),
+		})
+	}
+
+	return walked, nil
+}
+
+// move picks the next scale index. Moves that would leave the range are
+// reflected back into it.
+func (w *Walk) move(rng *rand.Rand, idx, count int, total float64) int {
+	var delta int
+	r := rng.Float64() * total
+	switch {
+	case r < w.Up:
+		delta = 1
+	case r < w.Up+w.Down:
+		delta = -1
+	case r < w.Up+w.Down+w.Stay:
+		delta = 0
+	default:
+		delta = 2 + rng.Intn(3)
+		if rng.Intn(2) == 0 {
+			delta = -delta
+		}
+	}
+
+	next := idx + delta
+	if next < 0 || next >= count {
+		next = idx - delta
+	}
+	return max(0, min(next, count-1))
+}
+
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func newWalk(meta metaparser.PartMetadata, params map[string]interface{}, env Env) (Generator, error) {
+	length, ok := getIntParam(params, "length")
+	if !ok {
+		return nil, fmt.Errorf("walk: missing required parameter 'length'")
+	}
+
+	key, _ := getStringParam(params, "key")
+	scale, _ := getStringParam(params, "scale")
+
+	startName, _ := getStringParam(params, "start")
+	start, err := music.ParseNote(startName)
+	if err != nil {
+		return nil, fmt.Errorf("walk: start: %w", err)
+	}
+
+	// Range defaults to an octave either side of the start note
+	low, high := uint8(max(int(start)-12, 12)), uint8(min(int(start)+12, 127))
+	if r, ok := getStringParam(params, "range"); ok {
+		bounds := strings.Split(r, "-")
+		if len(bounds) != 2 {
+			return nil, fmt.Errorf("walk: range must be formatted low-high (e.g. c3-c5)")
+		}
+		low, err = music.ParseNote(bounds[0])
+		if err != nil {
+			return nil, fmt.Errorf("walk: range: %w", err)
+		}
+		high, err = music.ParseNote(bounds[1])
+		if err != nil {
+			return nil, fmt.Errorf("walk: range: %w", err)
+		}
+	}
+
+	up, _ := getFloatParam(params, "up")
+	down, _ := getFloatParam(params, "down")
+	stay, _ := getFloatParam(params, "stay")
+	leap, _ := getFloatParam(params, "leap")
+	rest, _ := getFloatParam(params, "rest")
+
+	durations, ok := getIntListParam(params, "durations")
+	if !ok {
+		return nil, fmt.Errorf("walk: durations must be a comma-separated list of numbers")
+	}
+
+	velocity, _ := getIntParam(params, "velocity")
+	variation, _ := getIntParam(params, "variation")
+	seed, _ := getIntParam(params, "seed")
+
+	return &Walk{
+		Key:       key,
+		Scale:     scale,
+		Start:     start,
+		Low:       low,
+		High:      high,
+		Length:    length,
+		Up:        up,
+		Down:      down,
+		Stay:      stay,
+		Leap:      leap,
+		Rest:      rest,
+		Durations: durations,
+		Velocity:  velocity,
+		Variation: variation,
+		Div:       meta.Div,
+		Seed:      int64(seed),
+	}, nil
+}
+
+func init() {
+	Register(Spec{
+		Name:        "walk",
+		Description: "Randomly walks up and down a scale",
+		Params: []Param{
+			{Name: "length", Type: IntParam, Required: true, Min: bound(0), Description: "Number of steps"},
+			{Name: "key", Type: StringParam, Default: "c", Description: "Root of the scale"},
+			{Name: "scale", Type: StringParam, Default: "major", Description: "Scale name"},
+			{Name: "start", Type: StringParam, Default: "c4", Description: "First note"},
+			{Name: "range", Type: StringParam, Description: "Lowest and highest notes (e.g. c3-c5); defaults to an octave either side of start"},
+			{Name: "up", Type: NumberParam, Default: "35", Min: bound(0), Description: "Weight of moving up a degree"},
+			{Name: "down", Type: NumberParam, Default: "35", Min: bound(0), Description: "Weight of moving down a degree"},
+			{Name: "stay", Type: NumberParam, Default: "15", Min: bound(0), Description: "Weight of repeating the note"},
+			{Name: "leap", Type: NumberParam, Default: "15", Min: bound(0), Description: "Weight of leaping 2-4 degrees"},
+			{Name: "rest", Type: NumberParam, Default: "0", Min: bound(0), Max: bound(100), Description: "Probability of a rest"},
+			{Name: "durations", Type: IntListParam, Default: "1", Min: bound(1), Description: "Note durations in steps to choose from"},
+			{Name: "velocity", Type: IntParam, Default: "100", Min: bound(1), Max: bound(127), Description: "Base velocity"},
+			{Name: "variation", Type: IntParam, Default: "0", Min: bound(0), Description: "Maximum random change to velocity"},
+			{Name: "seed", Type: IntParam, Default: "0", Description: "Random seed"},
+		},
+		Factory: newWalk,
+	})
+}
@@ -0,0 +1,209 @@
+package generators
+
+import (
+	"slices"
+	"testing"
+)
+
+// testWalk returns a walk over c major with only the given move weights
+func testWalk(up, down, stay, leap float64) *Walk {
+	return &Walk{
+		Key: "c", Scale: "major", Start: 60, Low: 60, High: 72,
+		Length: 16, Up: up, Down: down, Stay: stay, Leap: leap,
+		Durations: []int{1}, Velocity: 100, Div: 24,
+	}
+}
+
+// walkedNotes returns the note numbers of the notes a walk chose
+func walkedNotes(t *testing.T, w *Walk) []uint8 {
+	t.Helper()
+	walked, err := w.walk()
+	if err != nil {
+		t.Fatalf("walk() unexpected error: %v", err)
+	}
+	var notes []uint8
+	for _, n := range walked {
+		if !n.rest {
+			notes = append(notes, n.note)
+		}
+	}
+	return notes
+}
+
+func TestWalkSeed(t *testing.T) {
+	generate := func(seed int64) []string {
+		w := testWalk(35, 35, 15, 15)
+		w.Length = 32
+		w.Durations = []int{1, 2}
+		w.Seed = seed
+		steps, err := w.Generate()
+		if err != nil {
+			t.Fatalf("Generate() unexpected error: %v", err)
+		}
+		return steps
+	}
+
+	first := generate(5)
+	if len(first) != 32 {
+		t.Fatalf("Generate() returned %d steps, want 32", len(first))
+	}
+	if !slices.Equal(generate(5), first) {
+		t.Error("Generate() with the same seed returned different steps")
+	}
+	if slices.Equal(generate(6), first) {
+		t.Error("Generate() with a different seed returned the same steps")
+	}
+}
+
+func TestWalkRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		walk     *Walk
+		expected []uint8
+	}{
+		{
+			// Moves past the top of the range are reflected down
+			name:     "reflect at high",
+			walk:     testWalk(1, 0, 0, 0),
+			expected: []uint8{60, 62, 64, 65, 67, 69, 71, 72, 71, 72, 71, 72, 71, 72, 71, 72},
+		},
+		{
+			// Moves past the bottom of the range are reflected up
+			name:     "reflect at low",
+			walk:     testWalk(0, 1, 0, 0),
+			expected: []uint8{60, 62, 60, 62, 60, 62, 60, 62, 60, 62, 60, 62, 60, 62, 60, 62},
+		},
+		{
+			// The walk begins on the scale note closest to the start note
+			name: "start off the scale",
+			walk: func() *Walk {
+				w := testWalk(0, 0, 1, 0)
+				w.Start, w.Length = 66, 2
+				return w
+			}(),
+			expected: []uint8{65, 65},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := walkedNotes(t, tt.walk); !slices.Equal(got, tt.expected) {
+				t.Errorf("walk() notes = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+
+	// Leaps stay within the range
+	w := testWalk(0, 0, 0, 1)
+	w.Length = 64
+	for _, n := range walkedNotes(t, w) {
+		if n < w.Low || n > w.High {
+			t.Errorf("walk() note %d is outside %d-%d", n, w.Low, w.High)
+		}
+	}
+}
+
+func TestWalkDurations(t *testing.T) {
+	w := testWalk(35, 35, 15, 15)
+	w.Length = 30
+	w.Durations = []int{2, 4}
+	walked, err := w.walk()
+	if err != nil {
+		t.Fatalf("walk() unexpected error: %v", err)
+	}
+
+	total := 0
+	seen := map[int]bool{}
+	for i, n := range walked {
+		total += n.duration
+		if i < len(walked)-1 && !slices.Contains(w.Durations, n.duration) {
+			t.Errorf("walk() note %d lasts %d steps, which isn't in %v", i, n.duration, w.Durations)
+		}
+		seen[n.duration] = true
+	}
+	if total != w.Length {
+		t.Errorf("walk() durations add up to %d, want %d", total, w.Length)
+	}
+	if !seen[2] || !seen[4] {
+		t.Errorf("walk() used durations %v, want both of %v", seen, w.Durations)
+	}
+}
+
+func TestWalkRest(t *testing.T) {
+	count := func(rest float64) (notes, rests int) {
+		w := testWalk(35, 35, 15, 15)
+		w.Length = 64
+		w.Rest = rest
+		walked, err := w.walk()
+		if err != nil {
+			t.Fatalf("walk() unexpected error: %v", err)
+		}
+		for _, n := range walked {
+			if n.rest {
+				rests++
+			} else {
+				notes++
+			}
+		}
+		return notes, rests
+	}
+
+	if notes, rests := count(0); rests != 0 || notes != 64 {
+		t.Errorf("rest 0: %d notes and %d rests, want 64 and 0", notes, rests)
+	}
+	if notes, rests := count(100); notes != 0 || rests != 64 {
+		t.Errorf("rest 100: %d notes and %d rests, want 0 and 64", notes, rests)
+	}
+	if notes, rests := count(50); notes == 0 || rests == 0 {
+		t.Errorf("rest 50: %d notes and %d rests, want some of each", notes, rests)
+	}
+}
+
+func TestWalkErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(w *Walk)
+	}{
+		{name: "unknown scale", setup: func(w *Walk) { w.Scale = "nope" }},
+		{name: "unknown key", setup: func(w *Walk) { w.Key = "h" }},
+		{name: "low above high", setup: func(w *Walk) { w.Low, w.High = 72, 60 }},
+		{name: "no durations", setup: func(w *Walk) { w.Durations = nil }},
+		{name: "zero duration", setup: func(w *Walk) { w.Durations = []int{0} }},
+		{name: "no moves", setup: func(w *Walk) { w.Up, w.Down, w.Stay, w.Leap = 0, 0, 0, 0 }},
+		{name: "velocity", setup: func(w *Walk) { w.Velocity = 0 }},
+		{name: "no notes in range", setup: func(w *Walk) { w.Low, w.High = 61, 61 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := testWalk(35, 35, 15, 15)
+			tt.setup(w)
+			if _, err := w.Generate(); err == nil {
+				t.Error("Generate() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestWalkEvents(t *testing.T) {
+	w := testWalk(35, 35, 15, 15)
+	w.Length = 8
+	w.Durations = []int{2}
+	w.Variation = 10
+	w.Seed = 2
+	e, err := w.GenerateEvents()
+	if err != nil {
+		t.Fatalf("GenerateEvents() unexpected error: %v", err)
+	}
+	if e.Steps != 8 || len(e.Events) != 4 {
+		t.Fatalf("GenerateEvents() = %d steps and %d events, want 8 and 4", e.Steps, len(e.Events))
+	}
+	for i, ev := range e.Events {
+		if ev.Tick != i*48 || ev.Length != 48 {
+			t.Errorf("event %d at tick %d for %d ticks, want %d for 48", i, ev.Tick, ev.Length, i*48)
+		}
+		if ev.Velocity < 90 || ev.Velocity > 110 {
+			t.Errorf("event %d velocity = %d, want 90-110", i, ev.Velocity)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package sequence
+
+// Layer returns a copy of the arrangement to play alongside others. Its
+// parts, including those in nested arrangements, are copied so they keep
+// their own passes, random choices and regenerated steps. The copies show
+// the steps they play on the originals.
+func (a *Arrangement) Layer() *Arrangement {
+	return a.layer(map[*Part]*Part{}, map[*Arrangement]*Arrangement{})
+}
+
+// layer copies the arrangement, reusing the copies of parts and arrangements
+// it has already made so repeated steps still share them
+func (a *Arrangement) layer(parts map[*Part]*Part, arrangements map[*Arrangement]*Arrangement) *Arrangement {
+	if c, ok := arrangements[a]; ok {
+		return c
+	}
+	c := &Arrangement{
+		name:     a.name,
+		group:    a.group,
+		steps:    a.steps,
+		duration: a.duration,
+		warnings: a.warnings,
+		origin:   a,
+	}
+	arrangements[a] = c
+
+	c.Playables = make([][]Playable, len(a.Playables))
+	for i, stepPlayables := range a.Playables {
+		// The sync part at the end of each step is rebuilt from the copies
+		for _, playable := range stepPlayables[:len(stepPlayables)-1] {
+			switch p := playable.(type) {
+			case *Part:
+				c.Playables[i] = append(c.Playables[i], p.layer(parts))
+			case *Arrangement:
+				c.Playables[i] = append(c.Playables[i], p.layer(parts, arrangements))
+			}
+		}
+	}
+	c.appendSyncParts()
+	return c
+}
+
+// layer copies the part. A fill copy is copied from the copy of its source.
+func (p *Part) layer(parts map[*Part]*Part) *Part {
+	if c, ok := parts[p]; ok {
+		return c
+	}
+	if p.source != nil {
+		c := p.source.layer(parts).fillCopy()
+		parts[p] = c
+		return c
+	}
+
+	p.mu.RLock()
+	c := &Part{
+		name:        p.name,
+		group:       p.group,
+		channel:     p.channel,
+		div:         p.div,
+		drumMap:     p.drumMap,
+		steps:       p.steps,
+		stepMult:    p.stepMult,
+		StepMIDI:    p.StepMIDI,
+		expanded:    p.expanded,
+		duration:    p.duration,
+		bpm:         p.bpm,
+		offMessages: p.offMessages,
+		delay:       p.delay,
+		lead:        p.lead,
+		strum:       p.strum,
+		mono:        p.mono,
+		overlap:     p.overlap,
+		seed:        p.seed,
+		fill:        p.fill,
+		warnings:    p.warnings,
+		origin:      p,
+	}
+	p.mu.RUnlock()
+	if p.regen != nil {
+		c.regen = p.regen.layer()
+	}
+	parts[p] = c
+	return c
+}
+
+// layer copies the regen settings of a part, starting from its current seed
+func (r *regen) layer() *regen {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &regen{
+		every:    r.every,
+		base:     r.base,
+		seed:     r.seed,
+		frozen:   r.frozen,
+		generate: r.generate,
+	}
+}
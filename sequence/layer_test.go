@@ -0,0 +1,76 @@
+package sequence
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestArrangementLayer(t *testing.T) {
+	md := "```beef.part name:trigs\nc4[1:2]\n```\n\n" +
+		"```beef.part name:two loop:fill\nc5\n```\n\n" +
+		"```beef.gen.walk\nname:w\nregen:1\nlength:4\nseed:7\n```\n\n" +
+		"```beef.arrangement name:a\ntrigs two w\n```\n"
+	path := filepath.Join(t.TempDir(), "layer.md")
+	if err := os.WriteFile(path, []byte(md), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(path)
+	if err != nil {
+		t.Fatalf("New() unexpected error: %v", err)
+	}
+	a := s.Arrangements[0]
+	trigs, w := s.Parts[0], s.Parts[2]
+
+	l := a.Layer()
+	parts := l.Parts()
+	if len(parts) != 3 || parts[0] == trigs || parts[2] == w {
+		t.Fatalf("layer parts = %v, want copies of trigs, two and w", parts)
+	}
+
+	// Copies count their own passes
+	trigs.resetPasses()
+	parts[0].resetPasses()
+	if on, _ := trigs.Trigger(0, false); len(on) != 1 {
+		t.Errorf("trigs pass 1 played %d notes, want 1", len(on))
+	}
+	if on, _ := parts[0].Trigger(0, false); len(on) != 1 {
+		t.Errorf("layer's trigs pass 1 played %d notes, want 1", len(on))
+	}
+
+	// and regenerate their own steps
+	l.ResetPasses()
+	settle(parts[2])
+	for range 2 {
+		if err := l.Regenerate(); err != nil {
+			t.Fatalf("Regenerate() unexpected error: %v", err)
+		}
+		settle(parts[2])
+	}
+	if err := l.Regenerate(); err != nil {
+		t.Fatalf("Regenerate() unexpected error: %v", err)
+	}
+	if parts[2].Seed() == 7 || w.Seed() != 7 {
+		t.Errorf("seeds = %d and %d, want the layer's to change and w's to stay 7", parts[2].Seed(), w.Seed())
+	}
+
+	// The fill copy in the layer repeats the copy of its source
+	fill := l.Playables[0][1].(*Part)
+	if fill.source != parts[1] {
+		t.Errorf("layer's fill copy has source %p, want %p", fill.source, parts[1])
+	}
+
+	// Steps played by the layer show on the originals
+	fill.UpdateStep(0)
+	l.UpdateStep(0)
+	if p := s.Parts[1].CurrentStep(); p == nil || *p != 0 {
+		t.Errorf("two current step = %v, want 0", p)
+	}
+	if p := a.CurrentStep(); p == nil || *p != 0 {
+		t.Errorf("a current step = %v, want 0", p)
+	}
+	l.ClearSteps()
+	if a.CurrentStep() != nil || s.Parts[1].CurrentStep() != nil {
+		t.Error("steps still shown after clearing the layer's steps")
+	}
+}
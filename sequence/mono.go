@@ -0,0 +1,109 @@
+package sequence
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/odaacabeef/beefdown/midi"
+)
+
+// applyMono adds note offs to a mono part so each note ends when the next one
+// starts. Notes struck again end just before; others end overlap clock ticks
+// later, or when the note after starts if that's sooner. Parts loop, so the
+// last notes end when the first ones start.
+func (p *Part) applyMono() {
+	ticks := len(p.StepMIDI) * p.div
+
+	// Notes started and ended on each tick, including notes with conditions,
+	// and the earliest step starting notes on each tick
+	ons := map[int][]uint8{}
+	offs := map[int][]uint8{}
+	owner := map[int]int{}
+	add := func(step, tick int, msgs [][]byte) {
+		t := step*p.div + tick
+		if t < 0 || t >= ticks {
+			return
+		}
+		for _, m := range msgs {
+			switch {
+			case midi.IsNoteOff(m):
+				offs[t] = append(offs[t], m[1])
+			case midi.IsNoteOn(m):
+				ons[t] = append(ons[t], m[1])
+				if o, ok := owner[t]; !ok || step < o {
+					owner[t] = step
+				}
+			}
+		}
+	}
+	for i, s := range p.StepMIDI {
+		add(i, 0, s.Off)
+		add(i, 0, s.On)
+		for k, msgs := range s.Sub {
+			add(i, k, msgs)
+		}
+		for _, t := range s.Trigs {
+			add(i, 0, t.on)
+			for k, msgs := range t.sub {
+				add(i, k, msgs)
+			}
+		}
+	}
+	for t, msgs := range p.offMessages {
+		add(0, t, msgs)
+	}
+
+	onTicks := slices.Sorted(maps.Keys(ons))
+	for j, t := range onTicks {
+		prev := onTicks[(j+len(onTicks)-1)%len(onTicks)]
+		next := onTicks[(j+1)%len(onTicks)]
+		if next <= t {
+			next += ticks
+		}
+		for _, note := range slices.Compact(slices.Sorted(slices.Values(ons[prev]))) {
+			if ended(offs, note, prev, t) {
+				continue
+			}
+			switch due := t + p.overlap; {
+			case p.overlap == 0 || slices.Contains(ons[t], note):
+				p.monoOff(note, t, owner[t], true)
+			case due < next:
+				p.monoOff(note, due%ticks, due%ticks/p.div, false)
+			default:
+				p.monoOff(note, next%ticks, owner[next%ticks], true)
+			}
+		}
+	}
+}
+
+// ended reports whether note has an off after tick from and up to tick to,
+// wrapping around the end of the part
+func ended(offs map[int][]uint8, note uint8, from, to int) bool {
+	for t, notes := range offs {
+		between := t > from && t <= to
+		if from >= to {
+			between = t > from || t <= to
+		}
+		if between && slices.Contains(notes, note) {
+			return true
+		}
+	}
+	return false
+}
+
+// monoOff adds a note off on a tick of the part, sent by step. Before puts it
+// ahead of the step's other messages on the tick.
+func (p *Part) monoOff(note uint8, tick, step int, before bool) {
+	off := midi.NoteOff(p.channel-1, note, 0)
+	s := &p.StepMIDI[step]
+	k := tick - step*p.div
+	switch {
+	case k == 0:
+		// Sent before the step's on messages
+		s.Off = append(s.Off, off)
+	case before:
+		s.Sub = mergeSub(map[int][][]byte{k: {off}}, s.Sub)
+	default:
+		s.Sub = mergeSub(s.Sub, map[int][][]byte{k: {off}})
+	}
+}
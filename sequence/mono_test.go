@@ -0,0 +1,93 @@
+package sequence
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/odaacabeef/beefdown/sequence/generators"
+)
+
+func TestPartMono(t *testing.T) {
+	p := Part{
+		name:    "mono",
+		channel: 1,
+		div:     12,
+		mono:    true,
+		steps:   []step{"c4", "e4", "", "g4>3"},
+	}
+	if err := p.parseMIDI(); err != nil {
+		t.Fatalf("parseMIDI() unexpected error: %v", err)
+	}
+
+	// Each note ends as the next starts, and the last as the part loops
+	if want := [][]byte{{0x80, 60, 0}}; !reflect.DeepEqual(p.StepMIDI[1].Off, want) {
+		t.Errorf("StepMIDI[1].Off = %v, want %v", p.StepMIDI[1].Off, want)
+	}
+	if want := [][]byte{{0x80, 64, 0}, {0x90, 67, 100}}; !reflect.DeepEqual(p.StepMIDI[3].Sub[3], want) {
+		t.Errorf("StepMIDI[3].Sub[3] = %v, want %v", p.StepMIDI[3].Sub[3], want)
+	}
+	if want := [][]byte{{0x80, 67, 0}}; !reflect.DeepEqual(p.StepMIDI[0].Off, want) {
+		t.Errorf("StepMIDI[0].Off = %v, want %v", p.StepMIDI[0].Off, want)
+	}
+}
+
+func TestPartMonoOverlap(t *testing.T) {
+	p := Part{
+		name:    "legato",
+		channel: 1,
+		div:     12,
+		mono:    true,
+		overlap: 6,
+		steps:   []step{"c4:1", "e4", "e4", "g4", "a4<10"},
+	}
+	if err := p.parseMIDI(); err != nil {
+		t.Fatalf("parseMIDI() unexpected error: %v", err)
+	}
+
+	// Notes with durations have already ended
+	if off := p.StepMIDI[1].Sub[6]; len(off) != 0 {
+		t.Errorf("StepMIDI[1].Sub[6] = %v, want no messages", off)
+	}
+	// Notes struck again end before they start
+	if want := [][]byte{{0x80, 64, 0}}; !reflect.DeepEqual(p.StepMIDI[2].Off, want) {
+		t.Errorf("StepMIDI[2].Off = %v, want %v", p.StepMIDI[2].Off, want)
+	}
+	// Overlaps are cut short by the note after next
+	if want := [][]byte{{0x80, 64, 0}, {0x90, 69, 100}}; !reflect.DeepEqual(p.StepMIDI[4].Sub[-10], want) {
+		t.Errorf("StepMIDI[4].Sub[-10] = %v, want %v", p.StepMIDI[4].Sub[-10], want)
+	}
+	// Other notes overlap the next
+	if want := [][]byte{{0x80, 67, 0}}; !reflect.DeepEqual(p.StepMIDI[3].Sub[8], want) {
+		t.Errorf("StepMIDI[3].Sub[8] = %v, want %v", p.StepMIDI[3].Sub[8], want)
+	}
+	if want := [][]byte{{0x80, 69, 0}}; !reflect.DeepEqual(p.StepMIDI[0].Sub[6], want) {
+		t.Errorf("StepMIDI[0].Sub[6] = %v, want %v", p.StepMIDI[0].Sub[6], want)
+	}
+}
+
+func TestPartMonoEvents(t *testing.T) {
+	p := Part{
+		name:    "mono",
+		channel: 1,
+		div:     12,
+		mono:    true,
+	}
+	err := p.parseEvents(generators.Events{
+		Steps: 2,
+		Events: []generators.Event{
+			{Type: generators.NoteEvent, Tick: 0, Note: 60, Velocity: 100, Length: 24},
+			{Type: generators.NoteEvent, Tick: 12, Note: 64, Velocity: 100},
+		},
+	})
+	if err != nil {
+		t.Fatalf("parseEvents() unexpected error: %v", err)
+	}
+
+	// Generated notes end as the next starts, even with a longer length
+	if want := [][]byte{{0x80, 60, 0}}; !reflect.DeepEqual(p.StepMIDI[1].Off, want) {
+		t.Errorf("StepMIDI[1].Off = %v, want %v", p.StepMIDI[1].Off, want)
+	}
+	if want := [][]byte{{0x80, 64, 0}}; !reflect.DeepEqual(p.StepMIDI[0].Off, want) {
+		t.Errorf("StepMIDI[0].Off = %v, want %v", p.StepMIDI[0].Off, want)
+	}
+}
@@ -23,10 +23,17 @@ const (
 type SequenceMetadata struct {
 	BPM      float64
 	Loop     bool
+	Chase    bool   // Re-trigger sounding notes when playback starts mid-song
+	Launch   string // When queued playables launch: bar, beat or step
+	Smooth   bool   // Followers play ticks from a clock locked to the leader's
 	Sync     string
 	SyncIn   string
 	VoiceOut string
 	SyncOut  string
+
+	// Followers pause when no timing clock arrives for this many milliseconds
+	// while playing, or never if it's 0
+	SyncTimeout int
 }
 
 type PartMetadata struct {
@@ -34,6 +41,28 @@ type PartMetadata struct {
 	Group   string
 	Channel uint8
 	Div     int
+	Map     string
+	Fill    bool  // loop:fill repeats the part to fill arrangement steps
+	Seed    int64 // Seeds conditional notes (e.g. c4?50)
+	Delay   int   // Clock ticks every note is played late
+
+	// Strum spreads the notes of chords by clock ticks, from the lowest note
+	// to the highest unless StrumDown is set (strum:2 or strum:2-down)
+	Strum     int
+	StrumDown bool
+
+	// mode:mono ends each note when the next starts, or Overlap clock ticks
+	// after it for legato
+	Mono    bool
+	Overlap int
+}
+
+// checkDelay returns an error if the part's delay is negative
+func (m PartMetadata) checkDelay() error {
+	if m.Delay < 0 {
+		return fmt.Errorf("invalid delay: %d, expected a number of clock ticks", m.Delay)
+	}
+	return nil
 }
 
 type ArrangementMetadata struct {
@@ -41,6 +70,11 @@ type ArrangementMetadata struct {
 	Group string
 }
 
+type DrumMapMetadata struct {
+	Name  string
+	Notes map[string]string
+}
+
 type FuncArpeggiateMetadata struct {
 	PartMetadata
 	Notes  string
@@ -51,6 +85,7 @@ type FuncMetadata struct {
 	FuncType     string
 	PartMetadata PartMetadata
 	Params       map[string]interface{}
+	Regen        int // Re-run the generator every Regen loops; 0 never
 }
 
 func (t TokenType) String() string {
@@ -174,6 +209,12 @@ func tokenizeNumberOrIdentifier(runes []rune, start int) base.TokenizeResult {
 		i++
 	}
 
+	// A number followed by a comma starts a list (e.g. "1,2,4"), which is read
+	// as a string
+	if i < len(runes) && runes[i] == ',' {
+		return tokenizeIdentifier(runes, start)
+	}
+
 	return base.TokenizeResult{
 		Tokens: []base.Token{{Type: base.TokenType(NUMBER), Literal: string(runes[start:i])}},
 		NewPos: i,
@@ -367,6 +408,47 @@ func (fp *fieldParser) getInt(key string, defaultValue int) int {
 	return int(fp.getNumber(key, float64(defaultValue)))
 }
 
+// getStrum reads a number of clock ticks, optionally followed by a direction
+// (e.g. 2, 2-up or 2-down)
+func (fp *fieldParser) getStrum(key string) (int, bool, error) {
+	var value string
+	switch v := fp.node.Fields[key].(type) {
+	case nil:
+		return 0, false, nil
+	case *NumberNode:
+		if v.Value >= 1 && v.Value == float64(int(v.Value)) {
+			return int(v.Value), false, nil
+		}
+		value = fmt.Sprintf("%g", v.Value)
+	case *StringNode:
+		ticks, dir, _ := strings.Cut(v.Value, "-")
+		n, err := strconv.Atoi(ticks)
+		if err == nil && n >= 1 && (dir == "up" || dir == "down") {
+			return n, dir == "down", nil
+		}
+		value = v.Value
+	default:
+		value = v.TokenLiteral()
+	}
+	return 0, false, fmt.Errorf("invalid strum: %s, expected clock ticks (e.g. 2 or 2-down)", value)
+}
+
+// getMode reads mode:poly or mode:mono, and the overlap of mono parts
+func (fp *fieldParser) getMode() (bool, int, error) {
+	var mono bool
+	switch mode := fp.getString("mode", "poly"); mode {
+	case "poly":
+	case "mono":
+		mono = true
+	default:
+		return false, 0, fmt.Errorf("invalid mode: %s, expected poly or mono", mode)
+	}
+	if _, ok := fp.node.Fields["overlap"]; ok && !mono {
+		return false, 0, fmt.Errorf("overlap requires mode:mono")
+	}
+	return mono, fp.getInt("overlap", 0), nil
+}
+
 func (fp *fieldParser) getDiv(key string, defaultValue int) int {
 	divStr := fp.getString(key, "")
 	if divStr == "" {
@@ -398,14 +480,24 @@ func ParseSequenceMetadata(raw string) (SequenceMetadata, error) {
 	}
 
 	fp := newFieldParser(node)
-	return SequenceMetadata{
-		BPM:      fp.getNumber("bpm", 120),
-		Loop:     fp.getBoolean("loop", false),
-		Sync:     fp.getString("sync", "none"),
-		SyncIn:   fp.getString("syncin", ""),
-		VoiceOut: fp.getString("voiceout", ""),
-		SyncOut:  fp.getString("syncout", ""),
-	}, nil
+	meta := SequenceMetadata{
+		BPM:         fp.getNumber("bpm", 120),
+		Loop:        fp.getBoolean("loop", false),
+		Chase:       fp.getBoolean("chase", false),
+		Launch:      fp.getString("launch", "bar"),
+		Smooth:      fp.getBoolean("smooth", false),
+		Sync:        fp.getString("sync", "none"),
+		SyncIn:      fp.getString("syncin", ""),
+		VoiceOut:    fp.getString("voiceout", ""),
+		SyncOut:     fp.getString("syncout", ""),
+		SyncTimeout: fp.getInt("synctimeout", 1000),
+	}
+	switch meta.Launch {
+	case "bar", "beat", "step":
+	default:
+		return SequenceMetadata{}, fmt.Errorf("invalid launch: %s, expected bar, beat or step", meta.Launch)
+	}
+	return meta, nil
 }
 
 func ParsePartMetadata(raw string) (PartMetadata, error) {
@@ -416,12 +508,25 @@ func ParsePartMetadata(raw string) (PartMetadata, error) {
 	}
 
 	fp := newFieldParser(node)
-	return PartMetadata{
+	meta := PartMetadata{
 		Name:    fp.getString("name", "default"),
 		Group:   fp.getString("group", "default"),
 		Channel: fp.getUint8("ch", 1),
 		Div:     fp.getDiv("div", 24),
-	}, nil
+		Map:     fp.getString("map", ""),
+		Fill:    fp.getString("loop", "") == "fill",
+		Seed:    int64(fp.getInt("seed", 0)),
+		Delay:   fp.getInt("delay", 0),
+	}
+	meta.Strum, meta.StrumDown, err = fp.getStrum("strum")
+	if err != nil {
+		return PartMetadata{}, err
+	}
+	meta.Mono, meta.Overlap, err = fp.getMode()
+	if err != nil {
+		return PartMetadata{}, err
+	}
+	return meta, meta.checkDelay()
 }
 
 func ParseArrangementMetadata(raw string) (ArrangementMetadata, error) {
@@ -438,6 +543,37 @@ func ParseArrangementMetadata(raw string) (ArrangementMetadata, error) {
 	}, nil
 }
 
+// ParseDrumMapMetadata parses a drum map block. Every field other than name is
+// a drum name mapped to a note (e.g. "kick:c1") or MIDI note number ("kick:36").
+func ParseDrumMapMetadata(raw string) (DrumMapMetadata, error) {
+	parser := NewParser(raw)
+	node, err := parser.Parse()
+	if err != nil {
+		return DrumMapMetadata{}, err
+	}
+
+	fp := newFieldParser(node)
+	notes := make(map[string]string)
+	for key, value := range node.Fields {
+		if key == "name" {
+			continue
+		}
+		switch v := value.(type) {
+		case *StringNode:
+			notes[key] = v.Value
+		case *NumberNode:
+			notes[key] = strconv.Itoa(int(v.Value))
+		default:
+			return DrumMapMetadata{}, fmt.Errorf("invalid note for %s: %s", key, value.TokenLiteral())
+		}
+	}
+
+	return DrumMapMetadata{
+		Name:  fp.getString("name", "default"),
+		Notes: notes,
+	}, nil
+}
+
 func ParseFuncArpeggiateMetadata(raw string) (FuncArpeggiateMetadata, error) {
 	parser := NewParser(raw)
 	node, err := parser.Parse()
@@ -446,16 +582,21 @@ func ParseFuncArpeggiateMetadata(raw string) (FuncArpeggiateMetadata, error) {
 	}
 
 	fp := newFieldParser(node)
-	return FuncArpeggiateMetadata{
+	meta := FuncArpeggiateMetadata{
 		PartMetadata: PartMetadata{
 			Name:    fp.getString("name", "default"),
 			Group:   fp.getString("group", "default"),
 			Channel: fp.getUint8("ch", 1),
 			Div:     fp.getDiv("div", 24),
+			Map:     fp.getString("map", ""),
+			Fill:    fp.getString("loop", "") == "fill",
+			Seed:    int64(fp.getInt("seed", 0)),
+			Delay:   fp.getInt("delay", 0),
 		},
 		Notes:  fp.getString("notes", ""),
 		Length: fp.getInt("length", 1),
-	}, nil
+	}
+	return meta, meta.checkDelay()
 }
 
 // extractFuncType extracts the generator type name from the raw input
@@ -488,6 +629,40 @@ func ParseFuncMetadata(raw string) (FuncMetadata, error) {
 		Group:   fp.getString("group", "default"),
 		Channel: fp.getUint8("ch", 1),
 		Div:     fp.getDiv("div", 24),
+		Map:     fp.getString("map", ""),
+		Fill:    fp.getString("loop", "") == "fill",
+		Seed:    int64(fp.getInt("seed", 0)),
+		Delay:   fp.getInt("delay", 0),
+	}
+	if err := partMeta.checkDelay(); err != nil {
+		return FuncMetadata{}, err
+	}
+	partMeta.Strum, partMeta.StrumDown, err = fp.getStrum("strum")
+	if err != nil {
+		return FuncMetadata{}, err
+	}
+	partMeta.Mono, partMeta.Overlap, err = fp.getMode()
+	if err != nil {
+		return FuncMetadata{}, err
+	}
+
+	// regen:loop re-runs the generator every loop, regen:N every N loops
+	var regen int
+	if value, ok := node.Fields["regen"]; ok {
+		switch v := value.(type) {
+		case *StringNode:
+			if v.Value != "loop" {
+				return FuncMetadata{}, fmt.Errorf("invalid regen: %s, expected loop or a number of loops", v.Value)
+			}
+			regen = 1
+		case *NumberNode:
+			if v.Value < 1 || v.Value != float64(int(v.Value)) {
+				return FuncMetadata{}, fmt.Errorf("invalid regen: %g, expected loop or a number of loops", v.Value)
+			}
+			regen = int(v.Value)
+		default:
+			return FuncMetadata{}, fmt.Errorf("invalid regen: %s, expected loop or a number of loops", value.TokenLiteral())
+		}
 	}
 
 	// All fields in the node become params (including part fields)
@@ -501,5 +676,6 @@ func ParseFuncMetadata(raw string) (FuncMetadata, error) {
 		FuncType:     extractFuncType(raw),
 		PartMetadata: partMeta,
 		Params:       params,
+		Regen:        regen,
 	}, nil
 }
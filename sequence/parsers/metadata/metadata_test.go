@@ -12,56 +12,94 @@ func TestParseSequenceMetadata(t *testing.T) {
 		{
 			input: ".sequence\nbpm:120",
 			expected: SequenceMetadata{
-				BPM:      120,
-				Loop:     false,
-				Sync:     "none",
-				SyncIn:   "",
-				VoiceOut: "",
-				SyncOut:  "",
+				BPM:         120,
+				Loop:        false,
+				Launch:      "bar",
+				SyncTimeout: 1000,
+				Sync:        "none",
+				SyncIn:      "",
+				VoiceOut:    "",
+				SyncOut:     "",
 			},
 		},
 		{
 			input: ".sequence\nbpm:150\nloop:true",
 			expected: SequenceMetadata{
-				BPM:      150,
-				Loop:     true,
-				Sync:     "none",
-				SyncIn:   "",
-				VoiceOut: "",
-				SyncOut:  "",
+				BPM:         150,
+				Loop:        true,
+				Launch:      "bar",
+				SyncTimeout: 1000,
+				Sync:        "none",
+				SyncIn:      "",
+				VoiceOut:    "",
+				SyncOut:     "",
 			},
 		},
 		{
 			input: ".sequence\nbpm:100\nsync:leader\nvoiceout:'Crumar Seven'",
 			expected: SequenceMetadata{
-				BPM:      100,
-				Loop:     false,
-				Sync:     "leader",
-				SyncIn:   "",
-				VoiceOut: "Crumar Seven",
-				SyncOut:  "",
+				BPM:         100,
+				Loop:        false,
+				Launch:      "bar",
+				SyncTimeout: 1000,
+				Sync:        "leader",
+				SyncIn:      "",
+				VoiceOut:    "Crumar Seven",
+				SyncOut:     "",
 			},
 		},
 		{
-			input: ".sequence\nbpm:100\nsync:follower\nsyncin:'Ableton Live'",
+			input: ".sequence\nbpm:100\nsync:follower\nsyncin:'Ableton Live'\nsmooth:true\nsynctimeout:250",
 			expected: SequenceMetadata{
-				BPM:      100,
-				Loop:     false,
-				Sync:     "follower",
-				SyncIn:   "Ableton Live",
-				VoiceOut: "",
-				SyncOut:  "",
+				BPM:         100,
+				Loop:        false,
+				Launch:      "bar",
+				SyncTimeout: 250,
+				Smooth:      true,
+				Sync:        "follower",
+				SyncIn:      "Ableton Live",
+				VoiceOut:    "",
+				SyncOut:     "",
+			},
+		},
+		{
+			input: ".sequence\nbpm:100\nchase:true",
+			expected: SequenceMetadata{
+				BPM:         100,
+				Loop:        false,
+				Launch:      "bar",
+				SyncTimeout: 1000,
+				Chase:       true,
+				Sync:        "none",
+				SyncIn:      "",
+				VoiceOut:    "",
+				SyncOut:     "",
+			},
+		},
+		{
+			input: ".sequence\nlaunch:step",
+			expected: SequenceMetadata{
+				BPM:         120,
+				Loop:        false,
+				Launch:      "step",
+				SyncTimeout: 1000,
+				Sync:        "none",
+				SyncIn:      "",
+				VoiceOut:    "",
+				SyncOut:     "",
 			},
 		},
 		{
 			input: "",
 			expected: SequenceMetadata{
-				BPM:      120,
-				Loop:     false,
-				Sync:     "none",
-				SyncIn:   "",
-				VoiceOut: "",
-				SyncOut:  "",
+				BPM:         120,
+				Loop:        false,
+				Launch:      "bar",
+				SyncTimeout: 1000,
+				Sync:        "none",
+				SyncIn:      "",
+				VoiceOut:    "",
+				SyncOut:     "",
 			},
 		},
 	}
@@ -80,6 +118,18 @@ func TestParseSequenceMetadata(t *testing.T) {
 			if result.Loop != tt.expected.Loop {
 				t.Errorf("Loop = %v, want %v", result.Loop, tt.expected.Loop)
 			}
+			if result.Chase != tt.expected.Chase {
+				t.Errorf("Chase = %v, want %v", result.Chase, tt.expected.Chase)
+			}
+			if result.Launch != tt.expected.Launch {
+				t.Errorf("Launch = %s, want %s", result.Launch, tt.expected.Launch)
+			}
+			if result.Smooth != tt.expected.Smooth {
+				t.Errorf("Smooth = %v, want %v", result.Smooth, tt.expected.Smooth)
+			}
+			if result.SyncTimeout != tt.expected.SyncTimeout {
+				t.Errorf("SyncTimeout = %d, want %d", result.SyncTimeout, tt.expected.SyncTimeout)
+			}
 			if result.Sync != tt.expected.Sync {
 				t.Errorf("Sync = %s, want %s", result.Sync, tt.expected.Sync)
 			}
@@ -94,4 +144,202 @@ func TestParseSequenceMetadata(t *testing.T) {
 			}
 		})
 	}
+
+	if _, err := ParseSequenceMetadata(".sequence\nlaunch:phrase"); err == nil {
+		t.Errorf("ParseSequenceMetadata() expected error for launch:phrase")
+	}
+}
+
+func TestParsePartMetadataMap(t *testing.T) {
+	result, err := ParsePartMetadata(".part name:drums ch:10 map:gm")
+	if err != nil {
+		t.Fatalf("ParsePartMetadata() unexpected error: %v", err)
+	}
+	if result.Map != "gm" {
+		t.Errorf("Map = %s, want gm", result.Map)
+	}
+
+	result, err = ParsePartMetadata(".part name:lead")
+	if err != nil {
+		t.Fatalf("ParsePartMetadata() unexpected error: %v", err)
+	}
+	if result.Map != "" {
+		t.Errorf("Map = %s, want empty", result.Map)
+	}
+}
+
+func TestParseDrumMapMetadata(t *testing.T) {
+	result, err := ParseDrumMapMetadata(".drummap name:tr8s\nkick:c1\nsnare:38\nchh:f#1")
+	if err != nil {
+		t.Fatalf("ParseDrumMapMetadata() unexpected error: %v", err)
+	}
+
+	if result.Name != "tr8s" {
+		t.Errorf("Name = %s, want tr8s", result.Name)
+	}
+
+	expected := map[string]string{
+		"kick":  "c1",
+		"snare": "38",
+		"chh":   "f#1",
+	}
+	if len(result.Notes) != len(expected) {
+		t.Errorf("Notes has %d entries, want %d", len(result.Notes), len(expected))
+	}
+	for name, note := range expected {
+		if result.Notes[name] != note {
+			t.Errorf("Notes[%s] = %s, want %s", name, result.Notes[name], note)
+		}
+	}
+}
+
+func TestParseFuncMetadataLists(t *testing.T) {
+	result, err := ParseFuncMetadata(".gen.walk\nname:w\ndurations:1,2,4\nnotes:c4,e4\nlength:16")
+	if err != nil {
+		t.Fatalf("ParseFuncMetadata() unexpected error: %v", err)
+	}
+
+	durations, ok := result.Params["durations"].(*StringNode)
+	if !ok {
+		t.Fatalf("durations = %T, want *StringNode", result.Params["durations"])
+	}
+	if durations.Value != "1,2,4" {
+		t.Errorf("durations = %s, want 1,2,4", durations.Value)
+	}
+
+	notes, ok := result.Params["notes"].(*StringNode)
+	if !ok || notes.Value != "c4,e4" {
+		t.Errorf("notes = %v, want c4,e4", result.Params["notes"])
+	}
+
+	length, ok := result.Params["length"].(*NumberNode)
+	if !ok || length.Value != 16 {
+		t.Errorf("length = %v, want 16", result.Params["length"])
+	}
+}
+
+func TestParseFuncMetadataRegen(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+		err      bool
+	}{
+		{input: ".gen.euclidean\npulses:3", expected: 0},
+		{input: ".gen.euclidean\nregen:loop", expected: 1},
+		{input: ".gen.euclidean\nregen:4", expected: 4},
+		{input: ".gen.euclidean\nregen:0", err: true},
+		{input: ".gen.euclidean\nregen:always", err: true},
+	}
+
+	for _, tt := range tests {
+		result, err := ParseFuncMetadata(tt.input)
+		if tt.err {
+			if err == nil {
+				t.Errorf("ParseFuncMetadata(%q) expected error", tt.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseFuncMetadata(%q) unexpected error: %v", tt.input, err)
+			continue
+		}
+		if result.Regen != tt.expected {
+			t.Errorf("ParseFuncMetadata(%q) regen = %d, want %d", tt.input, result.Regen, tt.expected)
+		}
+	}
+}
+
+func TestParsePartMetadataStrum(t *testing.T) {
+	tests := []struct {
+		input string
+		ticks int
+		down  bool
+		err   bool
+	}{
+		{input: ".part name:gtr", ticks: 0},
+		{input: ".part name:gtr strum:2", ticks: 2},
+		{input: ".part name:gtr strum:3-up", ticks: 3},
+		{input: ".part name:gtr strum:3-down", ticks: 3, down: true},
+		{input: ".part name:gtr strum:0", err: true},
+		{input: ".part name:gtr strum:2-sideways", err: true},
+		{input: ".part name:gtr strum:down", err: true},
+	}
+
+	for _, tt := range tests {
+		result, err := ParsePartMetadata(tt.input)
+		if tt.err {
+			if err == nil {
+				t.Errorf("ParsePartMetadata(%q) expected error", tt.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParsePartMetadata(%q) unexpected error: %v", tt.input, err)
+			continue
+		}
+		if result.Strum != tt.ticks || result.StrumDown != tt.down {
+			t.Errorf("ParsePartMetadata(%q) strum = %d down:%v, want %d down:%v", tt.input, result.Strum, result.StrumDown, tt.ticks, tt.down)
+		}
+	}
+}
+
+func TestParseFuncMetadataStrum(t *testing.T) {
+	result, err := ParseFuncMetadata(".gen.progression\nchords:I,IV strum:3-down")
+	if err != nil {
+		t.Fatalf("ParseFuncMetadata() unexpected error: %v", err)
+	}
+	if result.PartMetadata.Strum != 3 || !result.PartMetadata.StrumDown {
+		t.Errorf("ParseFuncMetadata() strum = %d down:%v, want 3 down:true", result.PartMetadata.Strum, result.PartMetadata.StrumDown)
+	}
+
+	if _, err := ParseFuncMetadata(".gen.progression\nchords:I,IV strum:down"); err == nil {
+		t.Error("ParseFuncMetadata() expected error for strum:down")
+	}
+}
+
+func TestParsePartMetadataMode(t *testing.T) {
+	tests := []struct {
+		input   string
+		mono    bool
+		overlap int
+		err     bool
+	}{
+		{input: ".part name:bass", mono: false},
+		{input: ".part name:bass mode:poly", mono: false},
+		{input: ".part name:bass mode:mono", mono: true},
+		{input: ".part name:bass mode:mono overlap:3", mono: true, overlap: 3},
+		{input: ".part name:bass mode:duo", err: true},
+		{input: ".part name:bass overlap:3", err: true},
+	}
+
+	for _, tt := range tests {
+		result, err := ParsePartMetadata(tt.input)
+		if tt.err {
+			if err == nil {
+				t.Errorf("ParsePartMetadata(%q) expected error", tt.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParsePartMetadata(%q) unexpected error: %v", tt.input, err)
+			continue
+		}
+		if result.Mono != tt.mono || result.Overlap != tt.overlap {
+			t.Errorf("ParsePartMetadata(%q) mono = %v overlap:%d, want %v overlap:%d", tt.input, result.Mono, result.Overlap, tt.mono, tt.overlap)
+		}
+	}
+}
+
+func TestParseFuncMetadataMode(t *testing.T) {
+	result, err := ParseFuncMetadata(".gen.walk\nlength:8 mode:mono overlap:2")
+	if err != nil {
+		t.Fatalf("ParseFuncMetadata() unexpected error: %v", err)
+	}
+	if !result.PartMetadata.Mono || result.PartMetadata.Overlap != 2 {
+		t.Errorf("ParseFuncMetadata() mono = %v overlap:%d, want true overlap:2", result.PartMetadata.Mono, result.PartMetadata.Overlap)
+	}
+
+	if _, err := ParseFuncMetadata(".gen.walk\nlength:8 overlap:2"); err == nil {
+		t.Error("ParseFuncMetadata() expected error for overlap without mode:mono")
+	}
 }
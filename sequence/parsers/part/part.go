@@ -3,6 +3,7 @@ package part
 import (
 	"fmt"
 	"strconv"
+	"strings"
 	"unicode"
 
 	"github.com/odaacabeef/beefdown/sequence/parsers/base"
@@ -15,6 +16,13 @@ const (
 	CHORD
 	NUMBER
 	COLON
+	ALIAS
+	PROBABILITY // ?N
+	CONDITION   // [...]
+	RATCHET     // xN or xN:decay
+	NUDGE       // >N or <N
+	STRUM       // ~N, ~N:up or ~N:down
+	PEDAL       // ped or ped-up
 )
 
 // Node represents a node in the AST
@@ -22,24 +30,125 @@ type Node interface {
 	TokenLiteral() string
 }
 
+// FillCondition makes a note depend on the fill toggle
+type FillCondition int
+
+const (
+	AnyFill  FillCondition = iota // Plays regardless of fill
+	FillOnly                      // [fill] plays only while fill is on
+	NotFill                       // [!fill] plays only while fill is off
+)
+
+// Condition decides whether a note plays each time its step is reached
+type Condition struct {
+	Probability int // Percent chance of playing (?N); 0 always plays
+	Cycle       int // Plays on pass Cycle of every Of passes ([Cycle:Of]); 0 every pass
+	Of          int
+	Fill        FillCondition
+}
+
+// Always reports whether the condition always plays
+func (c Condition) Always() bool {
+	return c == Condition{}
+}
+
+func (c Condition) String() string {
+	var s string
+	if c.Probability > 0 {
+		s += fmt.Sprintf("?%d", c.Probability)
+	}
+	if c.Cycle > 0 {
+		s += fmt.Sprintf("[%d:%d]", c.Cycle, c.Of)
+	}
+	switch c.Fill {
+	case FillOnly:
+		s += "[fill]"
+	case NotFill:
+		s += "[!fill]"
+	}
+	return s
+}
+
+// Ratchet retriggers a note several times within its step
+type Ratchet struct {
+	Count int  // Number of hits; 0 for a single hit
+	Decay bool // Lower the velocity of each hit
+}
+
+func (r Ratchet) String() string {
+	switch {
+	case r.Count == 0:
+		return ""
+	case r.Decay:
+		return fmt.Sprintf("x%d:decay", r.Count)
+	}
+	return fmt.Sprintf("x%d", r.Count)
+}
+
+// Nudge moves a note later (>N) or earlier (<N) by N clock ticks
+type Nudge int
+
+func (n Nudge) String() string {
+	switch {
+	case n > 0:
+		return fmt.Sprintf(">%d", n)
+	case n < 0:
+		return fmt.Sprintf("<%d", -n)
+	}
+	return ""
+}
+
+// Strum spreads the notes of a chord across clock ticks
+type Strum struct {
+	Ticks int  // Clock ticks between notes; 0 plays them together
+	Down  bool // Play from the highest note to the lowest
+}
+
+func (s Strum) String() string {
+	switch {
+	case s.Ticks == 0:
+		return ""
+	case s.Down:
+		return fmt.Sprintf("~%d:down", s.Ticks)
+	}
+	return fmt.Sprintf("~%d", s.Ticks)
+}
+
+// ParseStrum parses a strum without the leading ~ (e.g. "2" or "2:down")
+func ParseStrum(literal string) (Strum, error) {
+	ticks, dir, _ := strings.Cut(literal, ":")
+	n, err := strconv.Atoi(ticks)
+	if err != nil || n < 1 || (dir != "" && dir != "up" && dir != "down") {
+		return Strum{}, fmt.Errorf("invalid strum: ~%s", literal)
+	}
+	return Strum{Ticks: n, Down: dir == "down"}, nil
+}
+
 type NoteNode struct {
-	Note     string
-	Octave   int
-	Duration int
+	Note      string
+	Octave    int
+	Duration  int
+	Ratchet   Ratchet
+	Nudge     Nudge
+	Condition Condition
 }
 
 func (n *NoteNode) TokenLiteral() string {
 	if n.Duration > 0 {
-		return fmt.Sprintf("%s%d:%d", n.Note, n.Octave, n.Duration)
+		return fmt.Sprintf("%s%d:%d%s%s%s", n.Note, n.Octave, n.Duration, n.Ratchet, n.Nudge, n.Condition)
 	}
-	return fmt.Sprintf("%s%d", n.Note, n.Octave)
+	return fmt.Sprintf("%s%d%s%s%s", n.Note, n.Octave, n.Ratchet, n.Nudge, n.Condition)
 }
 
 type ChordNode struct {
-	Root     string
-	Quality  string
-	Bass     string
-	Duration int
+	Root      string
+	Quality   string
+	Bass      string
+	Duration  int
+	Ratchet   Ratchet
+	Nudge     Nudge
+	Strum     Strum
+	Condition Condition
 }
 
 func (c *ChordNode) TokenLiteral() string {
@@ -48,25 +157,148 @@ func (c *ChordNode) TokenLiteral() string {
 		chord += fmt.Sprintf("/%s", c.Bass)
 	}
 	if c.Duration > 0 {
-		return fmt.Sprintf("%s:%d", chord, c.Duration)
+		return fmt.Sprintf("%s:%d%s%s%s%s", chord, c.Duration, c.Ratchet, c.Nudge, c.Strum, c.Condition)
 	}
-	return chord
+	return chord + c.Ratchet.String() + c.Nudge.String() + c.Strum.String() + c.Condition.String()
+}
+
+// AliasNode is a named note resolved through a drum map (e.g. "kick")
+type AliasNode struct {
+	Name      string
+	Note      uint8
+	Duration  int
+	Ratchet   Ratchet
+	Nudge     Nudge
+	Condition Condition
+}
+
+func (a *AliasNode) TokenLiteral() string {
+	if a.Duration > 0 {
+		return fmt.Sprintf("%s:%d%s%s%s", a.Name, a.Duration, a.Ratchet, a.Nudge, a.Condition)
+	}
+	return a.Name + a.Ratchet.String() + a.Nudge.String() + a.Condition.String()
+}
+
+// PedalNode presses (ped) or releases (ped-up) the sustain pedal
+type PedalNode struct {
+	Down bool
+}
+
+func (p *PedalNode) TokenLiteral() string {
+	if p.Down {
+		return "ped"
+	}
+	return "ped-up"
+}
+
+// PedalController is the MIDI control change number of the sustain pedal
+const PedalController = 64
+
+// Value returns the sustain pedal's control change value
+func (p *PedalNode) Value() uint8 {
+	if p.Down {
+		return 127
+	}
+	return 0
 }
 
 // Parser represents the parser
 type Parser struct {
 	base.BaseParser
+	aliases map[string]uint8
 }
 
 func NewParser(input string) *Parser {
+	return NewAliasParser(input, nil)
+}
+
+// NewAliasParser returns a parser that also accepts the given note aliases
+// (e.g. from a drum map) in place of notes
+func NewAliasParser(input string, aliases map[string]uint8) *Parser {
 	return &Parser{
 		BaseParser: base.BaseParser{
-			Tokens:  tokenize(input),
+			Tokens:  tokenize(input, aliases),
 			Current: 0,
 		},
+		aliases: aliases,
 	}
 }
 
+func isAliasChar(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-'
+}
+
+// tokenizeAlias returns an ALIAS token if the word starting at start is a
+// known alias. ok is false when the word should be tokenized as a note.
+func tokenizeAlias(runes []rune, start int, aliases map[string]uint8) (result base.TokenizeResult, ok bool) {
+	if len(aliases) == 0 {
+		return base.TokenizeResult{}, false
+	}
+	i := start
+	for i < len(runes) && isAliasChar(runes[i]) {
+		i++
+	}
+	word := string(runes[start:i])
+	if _, ok := aliases[word]; !ok {
+		// The word may end with a ratchet (e.g. "chhx3")
+		x := strings.LastIndex(word, "x")
+		if x < 1 || !isRatchet([]rune(word), x) {
+			return base.TokenizeResult{}, false
+		}
+		word = word[:x]
+		if _, ok := aliases[word]; !ok {
+			return base.TokenizeResult{}, false
+		}
+		i = start + len([]rune(word))
+	}
+	token := base.Token{Type: base.TokenType(ALIAS), Literal: word}
+	return base.TokenizeResult{Tokens: []base.Token{token}, NewPos: i}, true
+}
+
+// tokenizePedal returns a PEDAL token if the word starting at start is ped
+// or ped-up
+func tokenizePedal(runes []rune, start int) (result base.TokenizeResult, ok bool) {
+	i := start
+	for i < len(runes) && isAliasChar(runes[i]) {
+		i++
+	}
+	word := string(runes[start:i])
+	if word != "ped" && word != "ped-up" {
+		return base.TokenizeResult{}, false
+	}
+	token := base.Token{Type: base.TokenType(PEDAL), Literal: word}
+	return base.TokenizeResult{Tokens: []base.Token{token}, NewPos: i}, true
+}
+
+// isRatchet reports whether runes[start] starts a ratchet (x followed by a
+// number)
+func isRatchet(runes []rune, start int) bool {
+	if runes[start] != 'x' || start+1 == len(runes) {
+		return false
+	}
+	for _, r := range runes[start+1:] {
+		if !unicode.IsDigit(r) {
+			return strings.ContainsRune(":?[><~", r) || unicode.IsSpace(r)
+		}
+	}
+	return true
+}
+
+// tokenizeRatchet reads a ratchet (e.g. x3 or x4:decay)
+func tokenizeRatchet(runes []rune, start int) base.TokenizeResult {
+	i := start + 1
+	for i < len(runes) && unicode.IsDigit(runes[i]) {
+		i++
+	}
+	literal := string(runes[start+1 : i])
+	if strings.HasPrefix(string(runes[i:]), ":decay") {
+		literal += ":decay"
+		i += len(":decay")
+	}
+	token := base.Token{Type: base.TokenType(RATCHET), Literal: literal}
+	return base.TokenizeResult{Tokens: []base.Token{token}, NewPos: i}
+}
+
 func tokenizeNote(runes []rune, start int) (base.TokenizeResult, error) {
 	firstLetter := string(runes[start])
 
@@ -113,9 +345,10 @@ func tokenizeChord(runes []rune, start int) (base.TokenizeResult, error) {
 		return base.TokenizeResult{}, fmt.Errorf("invalid chord root: %s", firstLetter)
 	}
 
-	// Read until we hit a space, colon, or end (slash is allowed for bass notes)
+	// Read until we hit a space, colon, ratchet, nudge, strum, condition, or
+	// end (slash is allowed for bass notes)
 	i := start
-	for i < len(runes) && runes[i] != ':' && !unicode.IsSpace(runes[i]) {
+	for i < len(runes) && !strings.ContainsRune(":?[x><~", runes[i]) && !unicode.IsSpace(runes[i]) {
 		i++
 	}
 
@@ -133,7 +366,71 @@ func tokenizeNumber(runes []rune, start int) base.TokenizeResult {
 	return base.TokenizeResult{Tokens: []base.Token{token}, NewPos: i}
 }
 
-func tokenize(input string) []base.Token {
+// tokenizeProbability reads the percentage following a '?'
+func tokenizeProbability(runes []rune, start int) (base.TokenizeResult, error) {
+	i := start + 1
+	for i < len(runes) && unicode.IsDigit(runes[i]) {
+		i++
+	}
+	if i == start+1 {
+		return base.TokenizeResult{}, fmt.Errorf("expected probability after ?")
+	}
+	token := base.Token{Type: base.TokenType(PROBABILITY), Literal: string(runes[start+1 : i])}
+	return base.TokenizeResult{Tokens: []base.Token{token}, NewPos: i}, nil
+}
+
+// tokenizeNudge reads the ticks following a '>' or '<'. The literal is
+// negative for '<'.
+func tokenizeNudge(runes []rune, start int) (base.TokenizeResult, error) {
+	i := start + 1
+	for i < len(runes) && unicode.IsDigit(runes[i]) {
+		i++
+	}
+	if i == start+1 {
+		return base.TokenizeResult{}, fmt.Errorf("expected clock ticks after %c", runes[start])
+	}
+	literal := string(runes[start+1 : i])
+	if runes[start] == '<' {
+		literal = "-" + literal
+	}
+	token := base.Token{Type: base.TokenType(NUDGE), Literal: literal}
+	return base.TokenizeResult{Tokens: []base.Token{token}, NewPos: i}, nil
+}
+
+// tokenizeStrum reads a strum (e.g. ~2 or ~2:down)
+func tokenizeStrum(runes []rune, start int) (base.TokenizeResult, error) {
+	i := start + 1
+	for i < len(runes) && unicode.IsDigit(runes[i]) {
+		i++
+	}
+	if i == start+1 {
+		return base.TokenizeResult{}, fmt.Errorf("expected clock ticks after ~")
+	}
+	literal := string(runes[start+1 : i])
+	for _, dir := range []string{":up", ":down"} {
+		if strings.HasPrefix(string(runes[i:]), dir) {
+			literal += dir
+			i += len(dir)
+		}
+	}
+	token := base.Token{Type: base.TokenType(STRUM), Literal: literal}
+	return base.TokenizeResult{Tokens: []base.Token{token}, NewPos: i}, nil
+}
+
+// tokenizeCondition reads a condition in brackets (e.g. [1:4] or [fill])
+func tokenizeCondition(runes []rune, start int) (base.TokenizeResult, error) {
+	i := start + 1
+	for i < len(runes) && runes[i] != ']' {
+		i++
+	}
+	if i == len(runes) {
+		return base.TokenizeResult{}, fmt.Errorf("missing ] after condition: %s", string(runes[start:i]))
+	}
+	token := base.Token{Type: base.TokenType(CONDITION), Literal: string(runes[start+1 : i])}
+	return base.TokenizeResult{Tokens: []base.Token{token}, NewPos: i + 1}, nil
+}
+
+func tokenize(input string, aliases map[string]uint8) []base.Token {
 	var tokens []base.Token
 	runes := []rune(input)
 	i := 0
@@ -145,15 +442,42 @@ func tokenize(input string) []base.Token {
 		case runes[i] == ':':
 			tokens = append(tokens, base.Token{Type: base.TokenType(COLON), Literal: ":"})
 			i++
+		case runes[i] == '?', runes[i] == '[', runes[i] == '>', runes[i] == '<', runes[i] == '~':
+			var result base.TokenizeResult
+			var err error
+			switch runes[i] {
+			case '?':
+				result, err = tokenizeProbability(runes, i)
+			case '[':
+				result, err = tokenizeCondition(runes, i)
+			case '~':
+				result, err = tokenizeStrum(runes, i)
+			default:
+				result, err = tokenizeNudge(runes, i)
+			}
+			if err != nil {
+				return []base.Token{{Type: base.ILLEGAL, Literal: err.Error()}}
+			}
+			tokens = append(tokens, result.Tokens...)
+			i = result.NewPos
 		case unicode.IsDigit(runes[i]):
 			result := tokenizeNumber(runes, i)
 			tokens = append(tokens, result.Tokens...)
 			i = result.NewPos
+		case i > 0 && !unicode.IsSpace(runes[i-1]) && isRatchet(runes, i):
+			// Ratchets follow a note without a space
+			result := tokenizeRatchet(runes, i)
+			tokens = append(tokens, result.Tokens...)
+			i = result.NewPos
 		case unicode.IsLetter(runes[i]):
 			var result base.TokenizeResult
 			var err error
 
-			if unicode.IsLower(runes[i]) {
+			if alias, ok := tokenizeAlias(runes, i, aliases); ok {
+				result = alias
+			} else if pedal, ok := tokenizePedal(runes, i); ok {
+				result = pedal
+			} else if unicode.IsLower(runes[i]) {
 				result, err = tokenizeNote(runes, i)
 			} else {
 				result, err = tokenizeChord(runes, i)
@@ -199,6 +523,10 @@ func (p *Parser) parseExpression() (Node, error) {
 		return p.parseNote()
 	case CHORD:
 		return p.parseChord()
+	case ALIAS:
+		return p.parseAlias()
+	case PEDAL:
+		return &PedalNode{Down: p.Advance().Literal == "ped"}, nil
 	default:
 		p.Advance()
 		return nil, nil
@@ -218,6 +546,11 @@ func (p *Parser) parseNote() (*NoteNode, error) {
 		return nil, err
 	}
 
+	t, err := p.parseTiming(timing{})
+	if err != nil {
+		return nil, err
+	}
+
 	duration := 0
 	if p.Match(base.TokenType(COLON)) {
 		if !p.Match(base.TokenType(NUMBER)) {
@@ -229,13 +562,151 @@ func (p *Parser) parseNote() (*NoteNode, error) {
 		}
 	}
 
+	t, err = p.parseTiming(t)
+	if err != nil {
+		return nil, err
+	}
+	if t.strum.Ticks > 0 {
+		return nil, fmt.Errorf("only chords can be strummed: %s", t.strum)
+	}
+
+	condition, err := p.parseCondition()
+	if err != nil {
+		return nil, err
+	}
+
 	return &NoteNode{
-		Note:     note,
-		Octave:   octave,
-		Duration: duration,
+		Note:      note,
+		Octave:    octave,
+		Duration:  duration,
+		Ratchet:   t.ratchet,
+		Nudge:     t.nudge,
+		Condition: condition,
+	}, nil
+}
+
+func (p *Parser) parseAlias() (*AliasNode, error) {
+	name := p.Advance().Literal
+
+	t, err := p.parseTiming(timing{})
+	if err != nil {
+		return nil, err
+	}
+
+	duration := 0
+	if p.Match(base.TokenType(COLON)) {
+		if !p.Match(base.TokenType(NUMBER)) {
+			return nil, fmt.Errorf("expected duration number after colon")
+		}
+		var err error
+		duration, err = strconv.Atoi(p.Previous().Literal)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	t, err = p.parseTiming(t)
+	if err != nil {
+		return nil, err
+	}
+	if t.strum.Ticks > 0 {
+		return nil, fmt.Errorf("only chords can be strummed: %s", t.strum)
+	}
+
+	condition, err := p.parseCondition()
+	if err != nil {
+		return nil, err
+	}
+
+	return &AliasNode{
+		Name:      name,
+		Note:      p.aliases[name],
+		Duration:  duration,
+		Ratchet:   t.ratchet,
+		Nudge:     t.nudge,
+		Condition: condition,
 	}, nil
 }
 
+// timing holds the modifiers that change when a note plays
+type timing struct {
+	ratchet Ratchet
+	nudge   Nudge
+	strum   Strum
+}
+
+// parseTiming parses a ratchet, nudge and strum, which can come before or
+// after a duration
+func (p *Parser) parseTiming(t timing) (timing, error) {
+	for {
+		switch {
+		case p.Match(base.TokenType(RATCHET)):
+			if t.ratchet.Count > 0 {
+				return timing{}, fmt.Errorf("more than one ratchet: x%s", p.Previous().Literal)
+			}
+			count, decay := strings.CutSuffix(p.Previous().Literal, ":decay")
+			c, err := strconv.Atoi(count)
+			if err != nil || c < 2 {
+				return timing{}, fmt.Errorf("invalid ratchet: x%s", p.Previous().Literal)
+			}
+			t.ratchet = Ratchet{Count: c, Decay: decay}
+		case p.Match(base.TokenType(NUDGE)):
+			ticks, err := strconv.Atoi(p.Previous().Literal)
+			if err != nil || ticks == 0 {
+				return timing{}, fmt.Errorf("nudge must be at least 1 clock tick")
+			}
+			if t.nudge != 0 {
+				return timing{}, fmt.Errorf("more than one nudge: %s", Nudge(ticks))
+			}
+			t.nudge = Nudge(ticks)
+		case p.Match(base.TokenType(STRUM)):
+			if t.strum.Ticks > 0 {
+				return timing{}, fmt.Errorf("more than one strum: ~%s", p.Previous().Literal)
+			}
+			strum, err := ParseStrum(p.Previous().Literal)
+			if err != nil {
+				return timing{}, err
+			}
+			t.strum = strum
+		default:
+			return t, nil
+		}
+	}
+}
+
+// parseCondition parses any probability and conditions following a note
+func (p *Parser) parseCondition() (Condition, error) {
+	var c Condition
+	for {
+		switch {
+		case p.Match(base.TokenType(PROBABILITY)):
+			n, err := strconv.Atoi(p.Previous().Literal)
+			if err != nil || n < 1 || n > 100 {
+				return Condition{}, fmt.Errorf("invalid probability: ?%s", p.Previous().Literal)
+			}
+			c.Probability = n
+		case p.Match(base.TokenType(CONDITION)):
+			cond := p.Previous().Literal
+			switch cond {
+			case "fill":
+				c.Fill = FillOnly
+			case "!fill":
+				c.Fill = NotFill
+			default:
+				cycle, of, ok := strings.Cut(cond, ":")
+				a, errA := strconv.Atoi(cycle)
+				b, errB := strconv.Atoi(of)
+				if !ok || errA != nil || errB != nil || a < 1 || a > b {
+					return Condition{}, fmt.Errorf("invalid condition: [%s]", cond)
+				}
+				c.Cycle, c.Of = a, b
+			}
+		default:
+			return c, nil
+		}
+	}
+}
+
 var validChordQualities = map[string]bool{
 	"m": true, "M": true, "5": true, "7": true, "9": true, "11": true, "13": true,
 	"dim": true, "aug": true, "sus": true, "m7": true, "M7": true, "mM7": true,
@@ -300,6 +771,11 @@ func (p *Parser) parseChord() (*ChordNode, error) {
 		return nil, fmt.Errorf("invalid chord quality: %s", quality)
 	}
 
+	t, err := p.parseTiming(timing{})
+	if err != nil {
+		return nil, err
+	}
+
 	duration := 0
 	if p.Match(base.TokenType(COLON)) {
 		if !p.Match(base.TokenType(NUMBER)) {
@@ -312,11 +788,24 @@ func (p *Parser) parseChord() (*ChordNode, error) {
 		}
 	}
 
+	t, err = p.parseTiming(t)
+	if err != nil {
+		return nil, err
+	}
+
+	condition, err := p.parseCondition()
+	if err != nil {
+		return nil, err
+	}
+
 	return &ChordNode{
-		Root:     root,
-		Quality:  quality,
-		Bass:     bass,
-		Duration: duration,
+		Root:      root,
+		Quality:   quality,
+		Bass:      bass,
+		Duration:  duration,
+		Ratchet:   t.ratchet,
+		Nudge:     t.nudge,
+		Strum:     t.strum,
+		Condition: condition,
 	}, nil
 }
-
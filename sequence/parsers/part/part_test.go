@@ -182,7 +182,7 @@ func TestChordTokenization(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.input, func(t *testing.T) {
-			tokens := tokenize(tt.input)
+			tokens := tokenize(tt.input, nil)
 			if len(tokens) != 2 { // Should have the chord token and EOF
 				t.Errorf("tokenize() got %d tokens, want 2 for input %q", len(tokens), tt.input)
 				return
@@ -222,7 +222,7 @@ func TestNoteTokenization(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.input, func(t *testing.T) {
-			tokens := tokenize(tt.input)
+			tokens := tokenize(tt.input, nil)
 			if len(tokens) < 2 { // Should have at least the note token and EOF
 				t.Errorf("tokenize() got %d tokens, want at least 2 for input %q", len(tokens), tt.input)
 				return
@@ -354,3 +354,354 @@ func TestNoteParsingWithOctaves(t *testing.T) {
 		})
 	}
 }
+
+func TestAliasParsing(t *testing.T) {
+	aliases := map[string]uint8{
+		"kick":  36,
+		"snare": 38,
+		"chh":   42,
+		"tom-1": 45,
+	}
+
+	tests := []struct {
+		input    string
+		name     string
+		note     uint8
+		duration int
+		wantErr  bool
+	}{
+		{"kick", "kick", 36, 0, false},
+		{"snare:2", "snare", 38, 2, false},
+		{"chh", "chh", 42, 0, false},
+		{"tom-1:4", "tom-1", 45, 4, false},
+
+		// Invalid aliases
+		{"kik", "", 0, 0, true},     // Unknown alias falls back to note parsing
+		{"kick:", "", 0, 0, true},   // Missing duration after colon
+		{"chhh", "", 0, 0, true},    // Aliases must match the whole word
+		{"snare:x", "", 0, 0, true}, // Invalid duration
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			parser := NewAliasParser(tt.input, aliases)
+			nodes, err := parser.Parse()
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Parse() expected error for input %q", tt.input)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("Parse() unexpected error for input %q: %v", tt.input, err)
+				return
+			}
+
+			if len(nodes) != 1 {
+				t.Errorf("Parse() expected 1 node, got %d for input %q", len(nodes), tt.input)
+				return
+			}
+
+			alias, ok := nodes[0].(*AliasNode)
+			if !ok {
+				t.Errorf("Parse() expected AliasNode, got %T for input %q", nodes[0], tt.input)
+				return
+			}
+
+			if alias.Name != tt.name {
+				t.Errorf("Parse() name = %q, want %q for input %q", alias.Name, tt.name, tt.input)
+			}
+			if alias.Note != tt.note {
+				t.Errorf("Parse() note = %d, want %d for input %q", alias.Note, tt.note, tt.input)
+			}
+			if alias.Duration != tt.duration {
+				t.Errorf("Parse() duration = %d, want %d for input %q", alias.Duration, tt.duration, tt.input)
+			}
+		})
+	}
+}
+
+func TestAliasWithNotes(t *testing.T) {
+	parser := NewAliasParser("kick c4:2 snare", map[string]uint8{"kick": 36, "snare": 38})
+	nodes, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	if len(nodes) != 3 {
+		t.Fatalf("Parse() expected 3 nodes, got %d", len(nodes))
+	}
+	if _, ok := nodes[0].(*AliasNode); !ok {
+		t.Errorf("Parse() node 0 = %T, want *AliasNode", nodes[0])
+	}
+	if _, ok := nodes[1].(*NoteNode); !ok {
+		t.Errorf("Parse() node 1 = %T, want *NoteNode", nodes[1])
+	}
+	if _, ok := nodes[2].(*AliasNode); !ok {
+		t.Errorf("Parse() node 2 = %T, want *AliasNode", nodes[2])
+	}
+}
+
+func TestConditionParsing(t *testing.T) {
+	aliases := map[string]uint8{"chh": 42}
+
+	tests := []struct {
+		input     string
+		condition Condition
+		wantErr   bool
+	}{
+		{"c4", Condition{}, false},
+		{"c4?60", Condition{Probability: 60}, false},
+		{"c4:2?25", Condition{Probability: 25}, false},
+		{"c4[1:4]", Condition{Cycle: 1, Of: 4}, false},
+		{"c4[fill]", Condition{Fill: FillOnly}, false},
+		{"c4:2?50[!fill]", Condition{Probability: 50, Fill: NotFill}, false},
+		{"CM7:4[2:2]", Condition{Cycle: 2, Of: 2}, false},
+		{"Am?80", Condition{Probability: 80}, false},
+		{"chh?30[3:4]", Condition{Probability: 30, Cycle: 3, Of: 4}, false},
+
+		// Invalid conditions
+		{"c4?", Condition{}, true},
+		{"c4?0", Condition{}, true},
+		{"c4?101", Condition{}, true},
+		{"c4[5:4]", Condition{}, true},
+		{"c4[0:4]", Condition{}, true},
+		{"c4[1:4", Condition{}, true},
+		{"c4[always]", Condition{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			nodes, err := NewAliasParser(tt.input, aliases).Parse()
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Parse() expected error for input %q", tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() unexpected error for input %q: %v", tt.input, err)
+			}
+			if len(nodes) != 1 {
+				t.Fatalf("Parse() expected 1 node, got %d for input %q", len(nodes), tt.input)
+			}
+
+			var condition Condition
+			switch n := nodes[0].(type) {
+			case *NoteNode:
+				condition = n.Condition
+			case *ChordNode:
+				condition = n.Condition
+			case *AliasNode:
+				condition = n.Condition
+			}
+			if condition != tt.condition {
+				t.Errorf("Parse() condition = %+v, want %+v for input %q", condition, tt.condition, tt.input)
+			}
+			if nodes[0].TokenLiteral() != tt.input {
+				t.Errorf("TokenLiteral() = %q, want %q", nodes[0].TokenLiteral(), tt.input)
+			}
+		})
+	}
+}
+
+func TestRatchetParsing(t *testing.T) {
+	aliases := map[string]uint8{"chh": 42}
+
+	tests := []struct {
+		input    string
+		literal  string
+		ratchet  Ratchet
+		duration int
+		wantErr  bool
+	}{
+		{"c1x3", "c1x3", Ratchet{Count: 3}, 0, false},
+		{"c1x4:decay", "c1x4:decay", Ratchet{Count: 4, Decay: true}, 0, false},
+		{"c1:2x3", "c1:2x3", Ratchet{Count: 3}, 2, false},
+		{"c1x3:2", "c1:2x3", Ratchet{Count: 3}, 2, false},
+		{"c1x2?50", "c1x2?50", Ratchet{Count: 2}, 0, false},
+		{"chhx3", "chhx3", Ratchet{Count: 3}, 0, false},
+		{"chhx4:decay[1:2]", "chhx4:decay[1:2]", Ratchet{Count: 4, Decay: true}, 0, false},
+		{"CMx2:4", "CM:4x2", Ratchet{Count: 2}, 4, false},
+
+		// Invalid ratchets
+		{"c1x1", "", Ratchet{}, 0, true},
+		{"c1x0", "", Ratchet{}, 0, true},
+		{"c1x2x3", "", Ratchet{}, 0, true},
+		{"c1 x3", "", Ratchet{}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			nodes, err := NewAliasParser(tt.input, aliases).Parse()
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Parse() expected error for input %q", tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() unexpected error for input %q: %v", tt.input, err)
+			}
+			if len(nodes) != 1 {
+				t.Fatalf("Parse() expected 1 node, got %d for input %q", len(nodes), tt.input)
+			}
+
+			var ratchet Ratchet
+			var duration int
+			switch n := nodes[0].(type) {
+			case *NoteNode:
+				ratchet, duration = n.Ratchet, n.Duration
+			case *ChordNode:
+				ratchet, duration = n.Ratchet, n.Duration
+			case *AliasNode:
+				ratchet, duration = n.Ratchet, n.Duration
+			}
+			if ratchet != tt.ratchet {
+				t.Errorf("Parse() ratchet = %+v, want %+v for input %q", ratchet, tt.ratchet, tt.input)
+			}
+			if duration != tt.duration {
+				t.Errorf("Parse() duration = %d, want %d for input %q", duration, tt.duration, tt.input)
+			}
+			if nodes[0].TokenLiteral() != tt.literal {
+				t.Errorf("TokenLiteral() = %q, want %q", nodes[0].TokenLiteral(), tt.literal)
+			}
+		})
+	}
+}
+
+func TestNudgeParsing(t *testing.T) {
+	aliases := map[string]uint8{"snare": 38}
+
+	tests := []struct {
+		input   string
+		literal string
+		nudge   Nudge
+		wantErr bool
+	}{
+		{"c4>2", "c4>2", 2, false},
+		{"c4<1", "c4<1", -1, false},
+		{"c4:2>3", "c4:2>3", 3, false},
+		{"c4>3:2", "c4:2>3", 3, false},
+		{"c4x3>2?50", "c4x3>2?50", 2, false},
+		{"c4>2x3", "c4x3>2", 2, false},
+		{"snare<2[fill]", "snare<2[fill]", -2, false},
+		{"CM7>4", "CM7>4", 4, false},
+
+		// Invalid nudges
+		{"c4>", "", 0, true},
+		{"c4>0", "", 0, true},
+		{"c4>2<1", "", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			nodes, err := NewAliasParser(tt.input, aliases).Parse()
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Parse() expected error for input %q", tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() unexpected error for input %q: %v", tt.input, err)
+			}
+			if len(nodes) != 1 {
+				t.Fatalf("Parse() expected 1 node, got %d for input %q", len(nodes), tt.input)
+			}
+
+			var nudge Nudge
+			switch n := nodes[0].(type) {
+			case *NoteNode:
+				nudge = n.Nudge
+			case *ChordNode:
+				nudge = n.Nudge
+			case *AliasNode:
+				nudge = n.Nudge
+			}
+			if nudge != tt.nudge {
+				t.Errorf("Parse() nudge = %d, want %d for input %q", nudge, tt.nudge, tt.input)
+			}
+			if nodes[0].TokenLiteral() != tt.literal {
+				t.Errorf("TokenLiteral() = %q, want %q", nodes[0].TokenLiteral(), tt.literal)
+			}
+		})
+	}
+}
+
+func TestStrumParsing(t *testing.T) {
+	tests := []struct {
+		input   string
+		literal string
+		strum   Strum
+		wantErr bool
+	}{
+		{"CM7~2", "CM7~2", Strum{Ticks: 2}, false},
+		{"CM7:4~2", "CM7:4~2", Strum{Ticks: 2}, false},
+		{"CM7~2:4", "CM7:4~2", Strum{Ticks: 2}, false},
+		{"CM7:4~3:down", "CM7:4~3:down", Strum{Ticks: 3, Down: true}, false},
+		{"CM7:4~3:up", "CM7:4~3", Strum{Ticks: 3}, false},
+		{"Am/E~1>2?50", "Am/E>2~1?50", Strum{Ticks: 1}, false},
+
+		// Invalid strums
+		{"CM7~", "", Strum{}, true},
+		{"CM7~0", "", Strum{}, true},
+		{"CM7~2~3", "", Strum{}, true},
+		{"c4~2", "", Strum{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			nodes, err := NewParser(tt.input).Parse()
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Parse() expected error for input %q", tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() unexpected error for input %q: %v", tt.input, err)
+			}
+			if len(nodes) != 1 {
+				t.Fatalf("Parse() expected 1 node, got %d for input %q", len(nodes), tt.input)
+			}
+			chord, ok := nodes[0].(*ChordNode)
+			if !ok {
+				t.Fatalf("Parse() expected ChordNode, got %T", nodes[0])
+			}
+			if chord.Strum != tt.strum {
+				t.Errorf("Parse() strum = %+v, want %+v for input %q", chord.Strum, tt.strum, tt.input)
+			}
+			if chord.TokenLiteral() != tt.literal {
+				t.Errorf("TokenLiteral() = %q, want %q", chord.TokenLiteral(), tt.literal)
+			}
+		})
+	}
+}
+
+func TestPedalParsing(t *testing.T) {
+	nodes, err := NewAliasParser("ped c4 ped-up", map[string]uint8{"kick": 36}).Parse()
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	if len(nodes) != 3 {
+		t.Fatalf("Parse() expected 3 nodes, got %d", len(nodes))
+	}
+	down, ok := nodes[0].(*PedalNode)
+	if !ok || !down.Down || down.Value() != 127 {
+		t.Errorf("nodes[0] = %#v, want pedal down", nodes[0])
+	}
+	up, ok := nodes[2].(*PedalNode)
+	if !ok || up.Down || up.Value() != 0 {
+		t.Errorf("nodes[2] = %#v, want pedal up", nodes[2])
+	}
+
+	if _, err := NewParser("pedal").Parse(); err == nil {
+		t.Error("Parse() expected error for pedal")
+	}
+}
@@ -1,13 +1,19 @@
 package sequence
 
 import (
+	"cmp"
 	"fmt"
+	"maps"
+	"math/rand"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/odaacabeef/beefdown/midi"
 	"github.com/odaacabeef/beefdown/music"
+	"github.com/odaacabeef/beefdown/sequence/generators"
 	partparser "github.com/odaacabeef/beefdown/sequence/parsers/part"
 )
 
@@ -16,23 +22,70 @@ type Part struct {
 	group   string
 	channel uint8
 	div     int
+	drumMap music.DrumMap
 
 	steps    []step
 	stepMult []int
 	StepMIDI []partStep
 
+	// expanded holds the notes of every step after multiplication
+	expanded []string
+
 	currentStep *int
 
+	// duration is how long the part plays for at bpm
 	duration time.Duration
+	bpm      float64
 
 	offMessages map[int][][]byte
 
+	// delay plays every note of the part late by a number of clock ticks. lead
+	// is how many clock ticks before their step the earliest notes start.
+	delay int
+	lead  int
+
+	// strum is the default strum for chords without one
+	strum partparser.Strum
+
+	// mono parts end each note when the next starts, or overlap clock ticks
+	// after it
+	mono    bool
+	overlap int
+
+	// regen is set for generated parts that re-run their generator on loops.
+	// mu guards the steps it replaces, which are shown while playing.
+	regen *regen
+	mu    sync.RWMutex
+
+	// seed and passes decide conditional notes during playback
+	seed   int64
+	passes int
+	rng    *rand.Rand
+	trigMu sync.Mutex
+
+	// fill repeats the part until the end of each arrangement step it's in.
+	// Arrangements play a copy of the part with source set to the original.
+	fill   bool
+	source *Part
+	ticks  int // Length of a filled copy in clock ticks
+
+	// origin is the part a layer's copy was made from, which shows its steps
+	origin *Part
+
 	warnings []string
 }
 
 type partStep struct {
 	On  [][]byte
 	Off [][]byte
+
+	// Sub holds messages sent on clock ticks other than the start of the
+	// step (e.g. ratchets and nudged notes), by tick relative to the start.
+	// Early notes have negative ticks and late ones can go past the step.
+	Sub map[int][][]byte
+
+	// Trigs holds notes with conditions, which are decided during playback
+	Trigs []trig
 }
 
 func (p *Part) parseMIDI() (err error) {
@@ -50,13 +103,16 @@ func (p *Part) parseMIDI() (err error) {
 
 	stepIdx := 0
 	p.offMessages = map[int][][]byte{}
+	p.lead = 0
 
 	var stepsMult []step
+	p.expanded = nil
 	for _, sd := range p.steps {
 		stepsMult = append(stepsMult, sd)
+		p.expanded = append(p.expanded, sd.notes())
 
 		// Parse the step using our AST parser
-		parser := partparser.NewParser(string(sd))
+		parser := partparser.NewAliasParser(string(sd), p.drumMap)
 		nodes, err := parser.Parse()
 		if err != nil {
 			return err
@@ -70,27 +126,32 @@ func (p *Part) parseMIDI() (err error) {
 				if err != nil {
 					return err
 				}
-				p.StepMIDI[stepIdx].On = append(p.StepMIDI[stepIdx].On, midi.NoteOn(p.channel-1, *note, 100))
-				if n.Duration > 0 {
-					offIdx := stepIdx + n.Duration
-					endOfBeat := offIdx*p.Div() - 1
-					if endOfBeat <= len(p.StepMIDI)*p.Div() {
-						p.offMessages[endOfBeat] = append(p.offMessages[endOfBeat], midi.NoteOff(p.channel-1, *note, 0))
-					}
+				err = p.addNotes(stepIdx, []uint8{*note}, n.Duration, n.Ratchet, n.Nudge, partparser.Strum{}, n.Condition)
+				if err != nil {
+					return err
+				}
+
+			case *partparser.AliasNode:
+				err = p.addNotes(stepIdx, []uint8{n.Note}, n.Duration, n.Ratchet, n.Nudge, partparser.Strum{}, n.Condition)
+				if err != nil {
+					return err
 				}
 
 			case *partparser.ChordNode:
+				// Chord tones share one condition so the chord plays whole
 				chordNotes := music.Chord(n.Root, n.Quality, n.Bass)
-				for _, note := range chordNotes {
-					p.StepMIDI[stepIdx].On = append(p.StepMIDI[stepIdx].On, midi.NoteOn(p.channel-1, note, 100))
-					if n.Duration > 0 {
-						offIdx := stepIdx + n.Duration
-						endOfBeat := offIdx*p.Div() - 1
-						if endOfBeat <= len(p.StepMIDI)*p.Div() {
-							p.offMessages[endOfBeat] = append(p.offMessages[endOfBeat], midi.NoteOff(p.channel-1, note, 0))
-						}
-					}
+				strum := n.Strum
+				if strum.Ticks == 0 {
+					strum = p.strum
 				}
+				err = p.addNotes(stepIdx, chordNotes, n.Duration, n.Ratchet, n.Nudge, strum, n.Condition)
+				if err != nil {
+					return err
+				}
+
+			case *partparser.PedalNode:
+				cc := midi.ControlChange(p.channel-1, partparser.PedalController, n.Value())
+				p.StepMIDI[stepIdx].On = append(p.StepMIDI[stepIdx].On, cc)
 			}
 		}
 
@@ -122,9 +183,13 @@ func (p *Part) parseMIDI() (err error) {
 						newStep.Off[i] = make([]byte, len(originalStep.Off[i]))
 						copy(newStep.Off[i], originalStep.Off[i])
 					}
+					newStep.Sub = maps.Clone(originalStep.Sub)
+					newStep.Trigs = slices.Clone(originalStep.Trigs)
 					p.StepMIDI[stepIdx] = newStep
+					p.expanded = append(p.expanded, sd.notes())
 				} else {
 					p.StepMIDI[stepIdx] = partStep{}
+					p.expanded = append(p.expanded, "")
 				}
 			} else {
 				// No modulo specified, repeat the step normally
@@ -141,16 +206,382 @@ func (p *Part) parseMIDI() (err error) {
 					newStep.Off[i] = make([]byte, len(originalStep.Off[i]))
 					copy(newStep.Off[i], originalStep.Off[i])
 				}
+				newStep.Sub = maps.Clone(originalStep.Sub)
+				newStep.Trigs = slices.Clone(originalStep.Trigs)
 				p.StepMIDI[stepIdx] = newStep
+				p.expanded = append(p.expanded, sd.notes())
 			}
 			stepsMult = append(stepsMult, "")
 			stepIdx++
 		}
 	}
 	p.steps = stepsMult
+	if p.mono {
+		p.applyMono()
+	}
+	return nil
+}
+
+// addNotes adds notes starting at a step. Ratchets retrigger the notes
+// evenly within the step, ending each hit as the next starts. Nudges and the
+// part's delay move the notes by clock ticks; notes can't be early on the
+// first step. Strums spread the notes by clock ticks in order of pitch.
+func (p *Part) addNotes(stepIdx int, notes []uint8, duration int, r partparser.Ratchet, n partparser.Nudge, s partparser.Strum, c partparser.Condition) error {
+	hits := max(r.Count, 1)
+	notes, spread, err := p.spread(notes, r, n, s)
+	if err != nil {
+		return err
+	}
+
+	offset := int(n) + p.delay
+	if stepIdx == 0 {
+		offset = max(offset, 0)
+	}
+	p.lead = max(p.lead, -offset)
+
+	var on [][]byte
+	sub := map[int][][]byte{}
+	for h := range hits {
+		velocity := uint8(100)
+		if r.Decay {
+			velocity = uint8(100 * (hits - h) / hits)
+		}
+		for i, note := range notes {
+			tick := offset + spread[i] + h*p.Div()/hits
+			if h > 0 {
+				sub[tick] = append(sub[tick], midi.NoteOff(p.channel-1, note, 0))
+			}
+			if tick == 0 {
+				on = append(on, midi.NoteOn(p.channel-1, note, velocity))
+			} else {
+				sub[tick] = append(sub[tick], midi.NoteOn(p.channel-1, note, velocity))
+			}
+		}
+	}
+	p.StepMIDI[stepIdx].add(c, on, sub)
+
+	// Notes moved past the end of the part by a delay or nudge end on its last
+	// tick, like filled parts
+	if duration > 0 && stepIdx+duration <= len(p.StepMIDI) {
+		endOfNote := min(stepIdx*p.Div()+offset+duration*p.Div()-1, len(p.StepMIDI)*p.Div()-1)
+		for _, note := range notes {
+			p.offMessages[endOfNote] = append(p.offMessages[endOfNote], midi.NoteOff(p.channel-1, note, 0))
+		}
+	}
+	return nil
+}
+
+// spread checks the ratchet, nudge and strum of notes struck together, and
+// returns the notes in the order they're strummed with how many clock ticks
+// after the first each starts
+func (p *Part) spread(notes []uint8, r partparser.Ratchet, n partparser.Nudge, s partparser.Strum) ([]uint8, []int, error) {
+	hits := max(r.Count, 1)
+	if hits > p.Div() {
+		return nil, nil, fmt.Errorf("%s: x%d is more hits than the %d clock ticks in a step", p.name, hits, p.Div())
+	}
+	if int(n) <= -p.Div() || int(n) >= p.Div() {
+		return nil, nil, fmt.Errorf("%s: %s is more than the %d clock ticks in a step", p.name, n, p.Div())
+	}
+
+	spread := make([]int, len(notes))
+	if s.Ticks > 0 && len(notes) > 1 {
+		notes = slices.Sorted(slices.Values(notes))
+		if s.Down {
+			slices.Reverse(notes)
+		}
+		for i := range notes {
+			spread[i] = i * s.Ticks
+		}
+		if last := spread[len(notes)-1] + (hits-1)*p.Div()/hits; last >= p.Div() {
+			return nil, nil, fmt.Errorf("%s: %s is longer than the %d clock ticks in a step", p.name, s, p.Div())
+		}
+	}
+	return notes, spread, nil
+}
+
+// parseEvents builds the part from generated events. Events with conditions
+// are decided during playback, from the step of the earliest event sharing
+// the decision.
+func (p *Part) parseEvents(e generators.Events) error {
+	p.StepMIDI = make([]partStep, e.Steps)
+	p.offMessages = map[int][][]byte{}
+	p.lead = 0
+
+	spread, err := p.strumEvents(e.Events)
+	if err != nil {
+		return err
+	}
+
+	// Messages of events with conditions, by event, and the events sharing
+	// each decision in the order they're first seen
+	msgs := make([][]byte, len(e.Events))
+	trigs := map[int][]int{}
+	var order []int
+
+	ticks := e.Steps * p.Div()
+	for i, ev := range e.Events {
+		if ev.Tick < 0 || ev.Tick >= ticks {
+			return fmt.Errorf("%s: event at tick %d is outside the part", p.name, ev.Tick)
+		}
+		if err := ev.Check(); err != nil {
+			return fmt.Errorf("%s: event %d: %w", p.name, i, err)
+		}
+
+		channel := p.channel
+		if ev.Channel > 0 {
+			channel = ev.Channel
+		}
+
+		var msg []byte
+		switch ev.Type {
+		case generators.NoteEvent:
+			msg = midi.NoteOn(channel-1, ev.Note, ev.Velocity)
+			if ev.Length > 0 && ev.Tick+ev.Length <= ticks {
+				endOfNote := min(ev.Tick+p.delay+ev.Length-1, ticks-1)
+				p.offMessages[endOfNote] = append(p.offMessages[endOfNote], midi.NoteOff(channel-1, ev.Note, 0))
+			}
+		case generators.CCEvent:
+			msg = midi.ControlChange(channel-1, ev.Controller, ev.Value)
+		default:
+			return fmt.Errorf("%s: unknown event type %d", p.name, ev.Type)
+		}
+
+		if !ev.Condition.Always() {
+			key := ev.Trig
+			if key == 0 {
+				key = -1 - i
+			}
+			if _, ok := trigs[key]; !ok {
+				order = append(order, key)
+			}
+			trigs[key] = append(trigs[key], i)
+			msgs[i] = msg
+			continue
+		}
+
+		stepIdx, tick := ev.Tick/p.Div(), ev.Tick%p.Div()+p.delay+spread[i]
+		if tick == 0 {
+			p.StepMIDI[stepIdx].On = append(p.StepMIDI[stepIdx].On, msg)
+		} else {
+			p.StepMIDI[stepIdx].Sub = mergeSub(p.StepMIDI[stepIdx].Sub, map[int][][]byte{tick: {msg}})
+		}
+	}
+
+	for _, key := range order {
+		group := trigs[key]
+		stepIdx := e.Events[group[0]].Tick / p.Div()
+		for _, i := range group {
+			stepIdx = min(stepIdx, e.Events[i].Tick/p.Div())
+		}
+		var on [][]byte
+		sub := map[int][][]byte{}
+		for _, i := range group {
+			tick := e.Events[i].Tick - stepIdx*p.Div() + p.delay + spread[i]
+			if tick == 0 {
+				on = append(on, msgs[i])
+			} else {
+				sub[tick] = append(sub[tick], msgs[i])
+			}
+		}
+		p.StepMIDI[stepIdx].add(e.Events[group[0]].Condition, on, sub)
+	}
+
+	// Display the generator's text, or describe the notes of each step
+	text := e.Text
+	if len(text) != e.Steps {
+		text = make([]string, e.Steps)
+		for _, ev := range e.Events {
+			if ev.Type != generators.NoteEvent {
+				continue
+			}
+			name, err := music.NoteName(ev.Note)
+			if err != nil {
+				continue
+			}
+			if ev.Length > 0 && ev.Length%p.Div() == 0 {
+				name = fmt.Sprintf("%s:%d", name, ev.Length/p.Div())
+			}
+			stepIdx := ev.Tick / p.Div()
+			text[stepIdx] = strings.TrimSpace(text[stepIdx] + " " + name)
+		}
+	}
+	p.steps = nil
+	p.expanded = nil
+	for _, t := range text {
+		p.steps = append(p.steps, step(t))
+		p.expanded = append(p.expanded, t)
+	}
+
+	if p.mono {
+		p.applyMono()
+	}
 	return nil
 }
 
+// strumEvents returns how many clock ticks late each event starts for the
+// part's strum. Notes starting together on a channel are spread in order of
+// pitch, like the notes of a chord.
+func (p *Part) strumEvents(events []generators.Event) ([]int, error) {
+	spread := make([]int, len(events))
+	if p.strum.Ticks == 0 {
+		return spread, nil
+	}
+
+	chords := map[[2]int][]int{}
+	for i, ev := range events {
+		if ev.Type == generators.NoteEvent {
+			key := [2]int{ev.Tick, int(ev.Channel)}
+			chords[key] = append(chords[key], i)
+		}
+	}
+	for _, chord := range chords {
+		if last := (len(chord) - 1) * p.strum.Ticks; last >= p.Div() {
+			return nil, fmt.Errorf("%s: %s is longer than the %d clock ticks in a step", p.name, p.strum, p.Div())
+		}
+		slices.SortStableFunc(chord, func(a, b int) int {
+			return cmp.Compare(events[a].Note, events[b].Note)
+		})
+		if p.strum.Down {
+			slices.Reverse(chord)
+		}
+		for j, i := range chord {
+			spread[i] = j * p.strum.Ticks
+		}
+	}
+	return spread, nil
+}
+
+// stepEvents converts step text to events, so parts built from generators
+// that return step text play like hand-written parts. Conditions, ratchets,
+// nudges, strums and multiplication are kept. Notes moved past the end of
+// the part are dropped, as they are when a hand-written part plays.
+func (p *Part) stepEvents(steps []string) (generators.Events, error) {
+	var e generators.Events
+	for _, t := range steps {
+		sd := step(t)
+		mult, _, err := sd.mult()
+		if err != nil {
+			return generators.Events{}, err
+		}
+		e.Steps += int(*mult)
+	}
+	ticks := e.Steps * p.Div()
+
+	stepIdx, trig := 0, 0
+	for _, t := range steps {
+		sd := step(t)
+		mult, modulo, err := sd.mult()
+		if err != nil {
+			return generators.Events{}, err
+		}
+		nodes, err := partparser.NewAliasParser(t, p.drumMap).Parse()
+		if err != nil {
+			return generators.Events{}, err
+		}
+
+		for j := range *mult {
+			if *modulo > 0 && j%*modulo != 0 {
+				e.Text = append(e.Text, "")
+				stepIdx++
+				continue
+			}
+			for _, node := range nodes {
+				var notes []uint8
+				var duration int
+				var r partparser.Ratchet
+				var n partparser.Nudge
+				var s partparser.Strum
+				var c partparser.Condition
+				switch node := node.(type) {
+				case *partparser.NoteNode:
+					note, err := music.Note(node.Note, strconv.Itoa(node.Octave))
+					if err != nil {
+						return generators.Events{}, err
+					}
+					notes, duration, r, n, c = []uint8{*note}, node.Duration, node.Ratchet, node.Nudge, node.Condition
+				case *partparser.AliasNode:
+					notes, duration, r, n, c = []uint8{node.Note}, node.Duration, node.Ratchet, node.Nudge, node.Condition
+				case *partparser.ChordNode:
+					// The part's strum is applied to events starting together
+					notes, duration, r, n, s, c = music.Chord(node.Root, node.Quality, node.Bass), node.Duration, node.Ratchet, node.Nudge, node.Strum, node.Condition
+				case *partparser.PedalNode:
+					e.Events = append(e.Events, generators.Event{
+						Type:       generators.CCEvent,
+						Tick:       stepIdx * p.Div(),
+						Controller: partparser.PedalController,
+						Value:      node.Value(),
+					})
+					continue
+				default:
+					continue
+				}
+
+				trig++
+				events, err := p.noteEvents(stepIdx, notes, duration, r, n, s, c, trig, ticks)
+				if err != nil {
+					return generators.Events{}, err
+				}
+				e.Events = append(e.Events, events...)
+			}
+			e.Text = append(e.Text, sd.notes())
+			stepIdx++
+		}
+	}
+	return e, nil
+}
+
+// noteEvents returns an event for each note of each hit of notes struck at a
+// step, like addNotes. Each hit lasts until the next, and the last until the
+// end of the duration.
+func (p *Part) noteEvents(stepIdx int, notes []uint8, duration int, r partparser.Ratchet, n partparser.Nudge, s partparser.Strum, c partparser.Condition, trig, ticks int) ([]generators.Event, error) {
+	hits := max(r.Count, 1)
+	notes, spread, err := p.spread(notes, r, n, s)
+	if err != nil {
+		return nil, err
+	}
+
+	offset := int(n)
+	if stepIdx == 0 {
+		offset = max(offset, 0)
+	}
+	start := stepIdx*p.Div() + offset
+	end := start + duration*p.Div()
+	if stepIdx+duration <= ticks/p.Div() {
+		end = min(end, ticks)
+	}
+
+	var events []generators.Event
+	for h := range hits {
+		velocity := uint8(100)
+		if r.Decay {
+			velocity = uint8(100 * (hits - h) / hits)
+		}
+		for i, note := range notes {
+			tick := start + spread[i] + h*p.Div()/hits
+			if tick >= ticks {
+				continue
+			}
+			length := 0
+			switch {
+			case h < hits-1:
+				length = start + spread[i] + (h+1)*p.Div()/hits - tick
+			case duration > 0:
+				length = end - tick
+			}
+			events = append(events, generators.Event{
+				Type:      generators.NoteEvent,
+				Tick:      tick,
+				Note:      note,
+				Velocity:  velocity,
+				Length:    length,
+				Condition: c,
+				Trig:      trig,
+			})
+		}
+	}
+	return events, nil
+}
+
 func (p *Part) Arrangement() *Arrangement {
 	a := Arrangement{
 		Playables: [][]Playable{
@@ -163,10 +594,32 @@ func (p *Part) Arrangement() *Arrangement {
 	return &a
 }
 
+// Channel returns the part's MIDI channel, from 1 to 16
+func (p *Part) Channel() uint8 {
+	return p.channel
+}
+
 func (p *Part) Div() int {
 	return p.div
 }
 
+// Lead returns how many clock ticks before their step the part's earliest
+// notes start. Playback triggers steps that far ahead.
+func (p *Part) Lead() int {
+	if p.source != nil {
+		return p.source.lead
+	}
+	return p.lead
+}
+
+// Ticks returns the length of the part in clock ticks
+func (p *Part) Ticks() int {
+	if p.source != nil {
+		return p.ticks
+	}
+	return len(p.StepMIDI) * p.div
+}
+
 func (p *Part) Name() string {
 	return p.name
 }
@@ -176,10 +629,20 @@ func (p *Part) Group() string {
 }
 
 func (p *Part) Title() string {
-	return fmt.Sprintf("%s ch:%d /%d (%s)\n\n", p.name, p.channel, p.div, p.duration.Round(time.Second))
+	title := fmt.Sprintf("%s ch:%d /%d (%s)", p.name, p.channel, p.div, p.Duration().Round(time.Second))
+	if p.regen != nil {
+		title += fmt.Sprintf(" seed:%d", p.Seed())
+		if p.Frozen() {
+			title += " (frozen)"
+		}
+	}
+	return title + "\n\n"
 }
 
 func (p *Part) Steps() (s string) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
 	var steps []string
 	for i, step := range p.steps {
 		current := " "
@@ -193,14 +656,29 @@ func (p *Part) Steps() (s string) {
 }
 
 func (p *Part) CurrentStep() *int {
+	if p.origin != nil {
+		return p.origin.CurrentStep()
+	}
 	return p.currentStep
 }
 
 func (p *Part) UpdateStep(i int) {
+	if p.source != nil {
+		p.source.UpdateStep(i % len(p.source.StepMIDI))
+		return
+	}
+	if p.origin != nil {
+		p.origin.UpdateStep(i)
+		return
+	}
 	p.currentStep = &i
 }
 
 func (p *Part) ClearStep() {
+	if p.origin != nil {
+		p.origin.ClearStep()
+		return
+	}
 	p.currentStep = nil
 }
 
@@ -209,11 +687,17 @@ func (p *Part) Warnings() []string {
 }
 
 func (p *Part) calcDuration(bpm float64) {
+	p.bpm = bpm
 	beatDuration := time.Duration(float64(time.Minute) / bpm)
 	beatCount := len(p.steps) / (24.0 / p.div)
 	p.duration = beatDuration * time.Duration(beatCount)
 }
 
 func (p *Part) Duration() time.Duration {
+	if p.source != nil {
+		return p.source.Duration()
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
 	return p.duration
 }
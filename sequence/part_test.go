@@ -0,0 +1,335 @@
+package sequence
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/odaacabeef/beefdown/sequence/generators"
+	partparser "github.com/odaacabeef/beefdown/sequence/parsers/part"
+)
+
+func TestPartParseEvents(t *testing.T) {
+	p := Part{
+		name:    "events",
+		channel: 2,
+		div:     6,
+	}
+
+	err := p.parseEvents(generators.Events{
+		Steps: 4,
+		Events: []generators.Event{
+			{Type: generators.NoteEvent, Tick: 0, Note: 60, Velocity: 90, Length: 12},
+			{Type: generators.NoteEvent, Tick: 8, Note: 64, Velocity: 70, Channel: 3},
+			{Type: generators.CCEvent, Tick: 6, Controller: 74, Value: 127},
+		},
+	})
+	if err != nil {
+		t.Fatalf("parseEvents() unexpected error: %v", err)
+	}
+
+	if len(p.StepMIDI) != 4 {
+		t.Fatalf("len(StepMIDI) = %d, want 4", len(p.StepMIDI))
+	}
+	if want := [][]byte{{0x91, 60, 90}}; !reflect.DeepEqual(p.StepMIDI[0].On, want) {
+		t.Errorf("StepMIDI[0].On = %v, want %v", p.StepMIDI[0].On, want)
+	}
+	if want := [][]byte{{0xB1, 74, 127}}; !reflect.DeepEqual(p.StepMIDI[1].On, want) {
+		t.Errorf("StepMIDI[1].On = %v, want %v", p.StepMIDI[1].On, want)
+	}
+
+	// Events between steps are sent on the ticks after the step starts
+	if want := [][]byte{{0x92, 64, 70}}; !reflect.DeepEqual(p.StepMIDI[1].Sub[2], want) {
+		t.Errorf("StepMIDI[1].Sub[2] = %v, want %v", p.StepMIDI[1].Sub[2], want)
+	}
+	if want := [][]byte{{0x81, 60, 0}}; !reflect.DeepEqual(p.offMessages[11], want) {
+		t.Errorf("offMessages[11] = %v, want %v", p.offMessages[11], want)
+	}
+
+	// Step text is described from the events
+	if p.steps[0] != "c4:2" || p.steps[1] != "e4" {
+		t.Errorf("steps = %q, want c4:2 and e4 in the first two steps", p.steps)
+	}
+}
+
+func TestPartParseEventsOutOfRange(t *testing.T) {
+	p := Part{
+		name:    "events",
+		channel: 1,
+		div:     24,
+	}
+
+	err := p.parseEvents(generators.Events{
+		Steps: 1,
+		Events: []generators.Event{
+			{Type: generators.NoteEvent, Tick: 24, Note: 60, Velocity: 100},
+		},
+	})
+	if err == nil {
+		t.Error("parseEvents() expected error for event after the end of the part")
+	}
+
+	err = p.parseEvents(generators.Events{
+		Steps: 1,
+		Events: []generators.Event{
+			{Type: generators.NoteEvent, Tick: 0, Note: 60, Velocity: 100},
+			{Type: generators.NoteEvent, Tick: 0, Note: 200, Velocity: 100},
+		},
+	})
+	if want := "events: event 1: note 200 is outside 0-127"; err == nil || err.Error() != want {
+		t.Errorf("parseEvents() error = %v, want %q", err, want)
+	}
+}
+
+func TestPartParseStepText(t *testing.T) {
+	p := Part{
+		name:    "text",
+		channel: 1,
+		div:     24,
+	}
+
+	// Generated step text is converted to events like a hand-written part
+	e, err := p.stepEvents([]string{"c4*3%2", "e4*2"})
+	if err != nil {
+		t.Fatalf("stepEvents() unexpected error: %v", err)
+	}
+	if err := p.parseEvents(e); err != nil {
+		t.Fatalf("parseEvents() unexpected error: %v", err)
+	}
+	if len(p.StepMIDI) != 5 {
+		t.Fatalf("len(StepMIDI) = %d, want 5", len(p.StepMIDI))
+	}
+	for i, note := range []byte{60, 0, 60, 64, 64} {
+		var want [][]byte
+		if note > 0 {
+			want = [][]byte{{0x90, note, 100}}
+		}
+		if !reflect.DeepEqual(p.StepMIDI[i].On, want) {
+			t.Errorf("StepMIDI[%d].On = %v, want %v", i, p.StepMIDI[i].On, want)
+		}
+	}
+	if want := []string{"c4", "", "c4", "e4", "e4"}; !reflect.DeepEqual(p.expanded, want) {
+		t.Errorf("expanded = %q, want %q", p.expanded, want)
+	}
+}
+
+func TestPartStepEvents(t *testing.T) {
+	p := Part{
+		name:    "text",
+		channel: 1,
+		div:     12,
+	}
+
+	e, err := p.stepEvents([]string{"c4:2<3", "CM?50", "d4x2:decay>2", "ped e4:1>6 g4x2>8"})
+	if err != nil {
+		t.Fatalf("stepEvents() unexpected error: %v", err)
+	}
+	half := partparser.Condition{Probability: 50}
+	note := generators.NoteEvent
+	want := []generators.Event{
+		// Notes can't be early on the first step
+		{Type: note, Tick: 0, Note: 60, Velocity: 100, Length: 24, Trig: 1},
+		// Chord notes share a decision
+		{Type: note, Tick: 12, Note: 60, Velocity: 100, Condition: half, Trig: 2},
+		{Type: note, Tick: 12, Note: 64, Velocity: 100, Condition: half, Trig: 2},
+		{Type: note, Tick: 12, Note: 67, Velocity: 100, Condition: half, Trig: 2},
+		// Each hit lasts until the next
+		{Type: note, Tick: 26, Note: 62, Velocity: 100, Length: 6, Trig: 3},
+		{Type: note, Tick: 32, Note: 62, Velocity: 50, Trig: 3},
+		{Type: generators.CCEvent, Tick: 36, Controller: 64, Value: 127},
+		// Notes moved past the end of the part end on its last tick, and
+		// hits after it are dropped
+		{Type: note, Tick: 42, Note: 64, Velocity: 100, Length: 6, Trig: 4},
+		{Type: note, Tick: 44, Note: 67, Velocity: 100, Length: 6, Trig: 5},
+	}
+	if e.Steps != 4 || !reflect.DeepEqual(e.Events, want) {
+		t.Errorf("stepEvents() = %d steps with %+v, want 4 with %+v", e.Steps, e.Events, want)
+	}
+
+	if err := p.parseEvents(e); err != nil {
+		t.Fatalf("parseEvents() unexpected error: %v", err)
+	}
+	second := p.StepMIDI[1]
+	if len(second.On) != 0 || len(second.Trigs) != 1 || len(second.Trigs[0].on) != 3 {
+		t.Errorf("StepMIDI[1] = %+v, want the chord as one trig", second)
+	}
+}
+
+func TestPartNudge(t *testing.T) {
+	p := Part{
+		name:    "nudge",
+		channel: 1,
+		div:     12,
+		delay:   1,
+		steps:   []step{"c4<3", "e4>2:1", "g4<3"},
+	}
+	if err := p.parseMIDI(); err != nil {
+		t.Fatalf("parseMIDI() unexpected error: %v", err)
+	}
+
+	// Notes can't be early on the first step
+	if want := [][]byte{{0x90, 60, 100}}; !reflect.DeepEqual(p.StepMIDI[0].On, want) {
+		t.Errorf("StepMIDI[0].On = %v, want %v", p.StepMIDI[0].On, want)
+	}
+	if want := [][]byte{{0x90, 64, 100}}; !reflect.DeepEqual(p.StepMIDI[1].Sub[3], want) {
+		t.Errorf("StepMIDI[1].Sub[3] = %v, want %v", p.StepMIDI[1].Sub[3], want)
+	}
+	if want := [][]byte{{0x80, 64, 0}}; !reflect.DeepEqual(p.offMessages[26], want) {
+		t.Errorf("offMessages[26] = %v, want %v", p.offMessages[26], want)
+	}
+	if want := [][]byte{{0x90, 67, 100}}; !reflect.DeepEqual(p.StepMIDI[2].Sub[-2], want) {
+		t.Errorf("StepMIDI[2].Sub[-2] = %v, want %v", p.StepMIDI[2].Sub[-2], want)
+	}
+	if p.Lead() != 2 {
+		t.Errorf("Lead() = %d, want 2", p.Lead())
+	}
+
+	p.steps = []step{"c4>12"}
+	if err := p.parseMIDI(); err == nil {
+		t.Error("parseMIDI() expected error for a nudge longer than a step")
+	}
+}
+
+func TestPartLateEnd(t *testing.T) {
+	tests := []struct {
+		name  string
+		delay int
+		steps []step
+	}{
+		{name: "delay", delay: 2, steps: []step{"c4:1", "e4:1"}},
+		{name: "nudge", steps: []step{"c4:1", "e4>3:1"}},
+		{name: "nudge and delay", delay: 2, steps: []step{"c4:1", "e4>3:1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Part{name: "late", channel: 1, div: 24, delay: tt.delay, steps: tt.steps}
+			if err := p.parseMIDI(); err != nil {
+				t.Fatalf("parseMIDI() unexpected error: %v", err)
+			}
+
+			// Notes moved past the end of the part end on its last tick
+			if want := [][]byte{{0x80, 64, 0}}; !reflect.DeepEqual(p.offMessages[47], want) {
+				t.Errorf("offMessages = %v, want %v on tick 47", p.offMessages, want)
+			}
+			if want := [][]byte{{0x80, 60, 0}}; !reflect.DeepEqual(p.offMessages[23+tt.delay], want) {
+				t.Errorf("offMessages = %v, want %v on tick %d", p.offMessages, want, 23+tt.delay)
+			}
+		})
+	}
+
+	p := Part{name: "late", channel: 1, div: 24, delay: 2}
+	err := p.parseEvents(generators.Events{
+		Steps: 2,
+		Events: []generators.Event{
+			{Type: generators.NoteEvent, Tick: 24, Note: 64, Velocity: 100, Length: 24},
+		},
+	})
+	if err != nil {
+		t.Fatalf("parseEvents() unexpected error: %v", err)
+	}
+	if want := map[int][][]byte{47: {{0x80, 64, 0}}}; !reflect.DeepEqual(p.offMessages, want) {
+		t.Errorf("parseEvents() offMessages = %v, want %v", p.offMessages, want)
+	}
+}
+
+func TestPartStrum(t *testing.T) {
+	p := Part{
+		name:    "strum",
+		channel: 1,
+		div:     24,
+		strum:   partparser.Strum{Ticks: 2, Down: true},
+		steps:   []step{"CM~3", "CM"},
+	}
+	if err := p.parseMIDI(); err != nil {
+		t.Fatalf("parseMIDI() unexpected error: %v", err)
+	}
+
+	// Up from the lowest note
+	first := p.StepMIDI[0]
+	if len(first.On) != 1 || first.On[0][1] != 60 {
+		t.Errorf("StepMIDI[0].On = %v, want c4", first.On)
+	}
+	if first.Sub[3][0][1] != 64 || first.Sub[6][0][1] != 67 {
+		t.Errorf("StepMIDI[0].Sub = %v, want e4 on tick 3 and g4 on tick 6", first.Sub)
+	}
+
+	// The part's default strums down from the highest note
+	second := p.StepMIDI[1]
+	if len(second.On) != 1 || second.On[0][1] != 67 {
+		t.Errorf("StepMIDI[1].On = %v, want g4", second.On)
+	}
+	if second.Sub[2][0][1] != 64 || second.Sub[4][0][1] != 60 {
+		t.Errorf("StepMIDI[1].Sub = %v, want e4 on tick 2 and c4 on tick 4", second.Sub)
+	}
+
+	p.steps = []step{"CM7~8"}
+	if err := p.parseMIDI(); err == nil {
+		t.Error("parseMIDI() expected error for a strum longer than a step")
+	}
+}
+
+func TestPartStrumEvents(t *testing.T) {
+	p := Part{
+		name:    "strum",
+		channel: 1,
+		div:     24,
+		strum:   partparser.Strum{Ticks: 2},
+	}
+	err := p.parseEvents(generators.Events{
+		Steps: 1,
+		Events: []generators.Event{
+			{Type: generators.NoteEvent, Tick: 0, Note: 67, Velocity: 100},
+			{Type: generators.NoteEvent, Tick: 0, Note: 60, Velocity: 100},
+			{Type: generators.NoteEvent, Tick: 0, Note: 64, Velocity: 100},
+			{Type: generators.NoteEvent, Tick: 12, Note: 72, Velocity: 100},
+		},
+	})
+	if err != nil {
+		t.Fatalf("parseEvents() unexpected error: %v", err)
+	}
+
+	// Notes starting together are strummed up from the lowest
+	s := p.StepMIDI[0]
+	if len(s.On) != 1 || s.On[0][1] != 60 {
+		t.Errorf("StepMIDI[0].On = %v, want c4", s.On)
+	}
+	if s.Sub[2][0][1] != 64 || s.Sub[4][0][1] != 67 || s.Sub[12][0][1] != 72 {
+		t.Errorf("StepMIDI[0].Sub = %v, want e4 on tick 2, g4 on tick 4 and c5 on tick 12", s.Sub)
+	}
+
+	p.strum = partparser.Strum{Ticks: 12}
+	err = p.parseEvents(generators.Events{
+		Steps: 1,
+		Events: []generators.Event{
+			{Type: generators.NoteEvent, Tick: 0, Note: 60, Velocity: 100},
+			{Type: generators.NoteEvent, Tick: 0, Note: 64, Velocity: 100},
+			{Type: generators.NoteEvent, Tick: 0, Note: 67, Velocity: 100},
+		},
+	})
+	if err == nil {
+		t.Error("parseEvents() expected error for a strum longer than a step")
+	}
+}
+
+func TestGeneratedStrum(t *testing.T) {
+	md := "```beef.gen.progression\nname:chords\nchords:I\nbeats:1\nstrum:2-down\n```\n"
+	path := filepath.Join(t.TempDir(), "strum.md")
+	if err := os.WriteFile(path, []byte(md), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(path)
+	if err != nil {
+		t.Fatalf("New() unexpected error: %v", err)
+	}
+
+	// Generated chords are strummed like a part's
+	first := s.Parts[0].StepMIDI[0]
+	if len(first.On) != 1 || first.On[0][1] != 67 {
+		t.Errorf("StepMIDI[0].On = %v, want g4", first.On)
+	}
+	if first.Sub[2][0][1] != 64 || first.Sub[4][0][1] != 60 {
+		t.Errorf("StepMIDI[0].Sub = %v, want e4 on tick 2 and c4 on tick 4", first.Sub)
+	}
+}
@@ -0,0 +1,208 @@
+package sequence
+
+import (
+	"math/rand"
+	"sync"
+
+	"github.com/odaacabeef/beefdown/sequence/generators"
+)
+
+// regen re-runs a part's generator as the arrangements it's in loop. Each
+// run uses a seed derived from the base seed and the number of passes, so
+// playback is repeatable. Runs happen ahead of the pass they're for, off the
+// playback goroutine, so slow generators don't hold up the clock. A run that
+// isn't ready when its pass starts is swapped in at the first pass after it's
+// ready.
+type regen struct {
+	every    int   // Passes between runs
+	base     int64 // Seed of the first pass
+	seed     int64 // Seed of the current events
+	passes   int   // Passes started since playback started
+	frozen   bool
+	generate func(seed int64) (*Part, error)
+	ahead    *pending // Run for an upcoming pass
+
+	mu sync.Mutex
+}
+
+// pending is a run of the generator in progress
+type pending struct {
+	seed int64
+	done chan struct{}
+	part *Part
+	err  error
+}
+
+// ready reports whether the run has finished
+func (r *pending) ready() bool {
+	select {
+	case <-r.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// due returns the seed of pass n
+func (r *regen) due(n int) int64 {
+	return r.base + int64(n/r.every)
+}
+
+// pass is called when an arrangement containing the part starts a pass. It
+// returns the part built from a finished run, if any, and starts the run for
+// the next pass.
+func (r *regen) pass() (*Part, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := r.passes
+	r.passes++
+	if r.frozen {
+		return nil, nil
+	}
+
+	var part *Part
+	var err error
+	if a := r.ahead; a != nil && a.ready() {
+		r.ahead = nil
+		part, err = a.part, a.err
+		if err == nil {
+			r.seed = a.seed
+		}
+	}
+	if r.ahead == nil {
+		r.prepare(r.due(n + 1))
+	}
+	return part, err
+}
+
+// prepare starts a run for seed unless its events are already current
+func (r *regen) prepare(seed int64) {
+	r.ahead = nil
+	if seed == r.seed {
+		return
+	}
+	a := &pending{seed: seed, done: make(chan struct{})}
+	r.ahead = a
+	go func() {
+		a.part, a.err = r.generate(seed)
+		close(a.done)
+	}()
+}
+
+// regenerate replaces the part's events with those of a finished run
+func (p *Part) regenerate(built *Part, err error) error {
+	if err != nil || built == nil {
+		return err
+	}
+	p.swap(built)
+	return nil
+}
+
+// build returns a copy of the part's settings with steps from events
+func (p *Part) build(e generators.Events) (*Part, error) {
+	built := &Part{
+		name:    p.name,
+		group:   p.group,
+		channel: p.channel,
+		div:     p.div,
+		drumMap: p.drumMap,
+		delay:   p.delay,
+		strum:   p.strum,
+		mono:    p.mono,
+		overlap: p.overlap,
+	}
+	if err := built.parseEvents(e); err != nil {
+		return nil, err
+	}
+	return built, nil
+}
+
+// swap replaces the part's steps with those of a built part, and updates its
+// duration for the new number of steps
+func (p *Part) swap(built *Part) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.steps = built.steps
+	p.stepMult = built.stepMult
+	p.StepMIDI = built.StepMIDI
+	p.expanded = built.expanded
+	p.offMessages = built.offMessages
+	p.lead = built.lead
+	p.calcDuration(p.bpm)
+}
+
+// Regen reports whether the part re-runs its generator on loops
+func (p *Part) Regen() bool {
+	return p.regen != nil
+}
+
+// Seed returns the seed of the part's current events
+func (p *Part) Seed() int64 {
+	if p.regen == nil {
+		return 0
+	}
+	p.regen.mu.Lock()
+	defer p.regen.mu.Unlock()
+	return p.regen.seed
+}
+
+// Frozen reports whether the part's seed is frozen
+func (p *Part) Frozen() bool {
+	if p.regen == nil {
+		return false
+	}
+	p.regen.mu.Lock()
+	defer p.regen.mu.Unlock()
+	return p.regen.frozen
+}
+
+// ToggleFreeze stops or resumes changing the part's seed on loops
+func (p *Part) ToggleFreeze() {
+	if p.regen == nil {
+		return
+	}
+	p.regen.mu.Lock()
+	defer p.regen.mu.Unlock()
+	p.regen.frozen = !p.regen.frozen
+	p.regen.ahead = nil
+}
+
+// Reroll picks a new random base seed. The part is regenerated once the new
+// events are ready, or immediately when now is true (i.e. when it isn't
+// playing). Rerolling a frozen part unfreezes it.
+func (p *Part) Reroll(now bool) error {
+	if p.regen == nil {
+		return nil
+	}
+	p.regen.mu.Lock()
+	defer p.regen.mu.Unlock()
+
+	p.regen.frozen = false
+	p.regen.base = rand.Int63n(1000000)
+	p.regen.passes = 0
+	if !now {
+		p.regen.prepare(p.regen.base)
+		return nil
+	}
+
+	p.regen.ahead = nil
+	built, err := p.regen.generate(p.regen.base)
+	if err != nil {
+		return err
+	}
+	p.regen.seed = p.regen.base
+	p.swap(built)
+	return nil
+}
+
+// reset restarts the pass count when playback starts, and starts the run for
+// the first pass if its seed has changed
+func (r *regen) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.passes = 0
+	if !r.frozen {
+		r.prepare(r.due(0))
+	}
+}
@@ -0,0 +1,152 @@
+package sequence
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/odaacabeef/beefdown/sequence/generators"
+)
+
+func TestArrangementRegenerate(t *testing.T) {
+	md := "```beef.gen.walk\nname:w\nregen:2\nlength:16\nseed:7\n```\n\n" +
+		"```beef.arrangement name:a\nw\n```\n"
+	path := filepath.Join(t.TempDir(), "regen.md")
+	if err := os.WriteFile(path, []byte(md), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(path)
+	if err != nil {
+		t.Fatalf("New() unexpected error: %v", err)
+	}
+	p, a := s.Parts[0], s.Arrangements[0]
+
+	// Runs are waited for so each is ready by the pass it's for
+	seeds := func() []int64 {
+		var seeds []int64
+		a.ResetPasses()
+		settle(p)
+		for range 5 {
+			if err := a.Regenerate(); err != nil {
+				t.Fatalf("Regenerate() unexpected error: %v", err)
+			}
+			seeds = append(seeds, p.Seed())
+			settle(p)
+		}
+		return seeds
+	}
+
+	first := p.Steps()
+	got := seeds()
+	want := []int64{7, 7, 8, 8, 9}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("seeds = %v, want %v", got, want)
+		}
+	}
+	if p.Steps() == first {
+		t.Error("steps didn't change after regenerating")
+	}
+
+	// Restarting playback starts from the base seed again
+	a.ResetPasses()
+	settle(p)
+	if err := a.Regenerate(); err != nil {
+		t.Fatalf("Regenerate() unexpected error: %v", err)
+	}
+	if p.Seed() != 7 || p.Steps() != first {
+		t.Errorf("seed = %d after restart, want 7 and the original steps", p.Seed())
+	}
+
+	// A frozen seed doesn't change
+	p.ToggleFreeze()
+	for _, seed := range seeds() {
+		if seed != 7 {
+			t.Errorf("frozen seed changed to %d", seed)
+		}
+	}
+}
+
+func TestRegenAhead(t *testing.T) {
+	p := &Part{name: "slow", channel: 1, div: 24}
+	steps := func(text string) generators.Events {
+		e, err := p.stepEvents([]string{text})
+		if err != nil {
+			t.Fatalf("stepEvents() unexpected error: %v", err)
+		}
+		return e
+	}
+	if err := p.parseEvents(steps("c4")); err != nil {
+		t.Fatalf("parseEvents() unexpected error: %v", err)
+	}
+	next := steps("e4")
+	release := make(chan struct{})
+	p.regen = &regen{
+		every: 1,
+		base:  1,
+		seed:  1,
+		generate: func(seed int64) (*Part, error) {
+			<-release
+			return p.build(next)
+		},
+	}
+
+	// The first pass starts the run for the second, which isn't ready when
+	// the second pass starts, so the part keeps its steps
+	for range 2 {
+		if err := p.regenerate(p.regen.pass()); err != nil {
+			t.Fatalf("regenerate() unexpected error: %v", err)
+		}
+	}
+	if p.Seed() != 1 || p.expanded[0] != "c4" {
+		t.Errorf("seed = %d and steps = %q before the run finished, want 1 and c4", p.Seed(), p.expanded)
+	}
+
+	// It's swapped in at the next pass once it's ready
+	close(release)
+	settle(p)
+	if err := p.regenerate(p.regen.pass()); err != nil {
+		t.Fatalf("regenerate() unexpected error: %v", err)
+	}
+	if p.Seed() != 2 || p.expanded[0] != "e4" {
+		t.Errorf("seed = %d and steps = %q after the run finished, want 2 and e4", p.Seed(), p.expanded)
+	}
+}
+
+// settle waits for the run of the part's generator in progress, if any
+func settle(p *Part) {
+	p.regen.mu.Lock()
+	ahead := p.regen.ahead
+	p.regen.mu.Unlock()
+	if ahead != nil {
+		<-ahead.done
+	}
+}
+
+func TestRegenDuration(t *testing.T) {
+	p := &Part{name: "grow", channel: 1, div: 24}
+	e, err := p.stepEvents([]string{"c4"})
+	if err != nil {
+		t.Fatalf("stepEvents() unexpected error: %v", err)
+	}
+	if err := p.parseEvents(e); err != nil {
+		t.Fatalf("parseEvents() unexpected error: %v", err)
+	}
+	p.calcDuration(120)
+
+	e, err = p.stepEvents([]string{"c4", "e4"})
+	if err != nil {
+		t.Fatalf("stepEvents() unexpected error: %v", err)
+	}
+	built, err := p.build(e)
+	if err != nil {
+		t.Fatalf("build() unexpected error: %v", err)
+	}
+	p.swap(built)
+
+	// The duration follows the new number of steps
+	if p.Duration() != time.Second {
+		t.Errorf("Duration() = %s after the part grew, want 1s", p.Duration())
+	}
+}
@@ -2,12 +2,18 @@ package sequence
 
 import (
 	"fmt"
+	"maps"
 	"os"
+	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/odaacabeef/beefdown/music"
 	"github.com/odaacabeef/beefdown/sequence/generators"
 	metaparser "github.com/odaacabeef/beefdown/sequence/parsers/metadata"
+	partparser "github.com/odaacabeef/beefdown/sequence/parsers/part"
 )
 
 type Sequence struct {
@@ -15,20 +21,29 @@ type Sequence struct {
 
 	BPM      float64
 	Loop     bool
+	Chase    bool
+	Launch   string
+	Smooth   bool
 	Sync     string
 	SyncIn   string
 	VoiceOut string
 	SyncOut  string
 
+	// Followers pause when the leader's clock stops for this long while
+	// playing, or never if it's 0
+	SyncTimeout time.Duration
+
 	Parts        []*Part
 	Arrangements []*Arrangement
+	DrumMaps     map[string]music.DrumMap
 
 	Playable []Playable
 }
 
 func New(p string) (*Sequence, error) {
 	s := Sequence{
-		Path: p,
+		Path:     p,
+		DrumMaps: map[string]music.DrumMap{},
 	}
 
 	err := s.parse()
@@ -64,16 +79,42 @@ func (s *Sequence) parse() error {
 				return err
 			}
 
+		case strings.HasPrefix(lines[0], ".drummap"):
+			meta, err := metaparser.ParseDrumMapMetadata(b[1])
+			if err != nil {
+				return err
+			}
+			dm := music.DrumMap{}
+			for name, n := range meta.Notes {
+				note, err := music.ParseNote(n)
+				if err != nil {
+					return fmt.Errorf("drummap %s: %s: %w", meta.Name, name, err)
+				}
+				dm[name] = note
+			}
+			s.DrumMaps[meta.Name] = dm
+
 		case strings.HasPrefix(lines[0], ".part"):
 			meta, err := metaparser.ParsePartMetadata(lines[0])
 			if err != nil {
 				return err
 			}
+			dm, err := s.drumMap(meta.Map)
+			if err != nil {
+				return err
+			}
 			p := Part{
 				name:    meta.Name,
 				group:   meta.Group,
 				channel: meta.Channel,
 				div:     meta.Div,
+				drumMap: dm,
+				fill:    meta.Fill,
+				seed:    meta.Seed,
+				delay:   meta.Delay,
+				strum:   partparser.Strum{Ticks: meta.Strum, Down: meta.StrumDown},
+				mono:    meta.Mono,
+				overlap: meta.Overlap,
 			}
 			for _, l := range lines[1:] {
 				p.steps = append(p.steps, step(l))
@@ -116,33 +157,78 @@ func (s *Sequence) parse() error {
 			if !ok {
 				return fmt.Errorf("unknown generator type: %s", meta.FuncType)
 			}
-
-			gen, err := factory(meta.PartMetadata, meta.Params)
-			if err != nil {
+			if err := generators.Validate(meta.FuncType, meta.Params); err != nil {
 				return err
 			}
 
-			stepStrings, err := gen.Generate()
+			dm, err := s.drumMap(meta.PartMetadata.Map)
 			if err != nil {
 				return err
 			}
 
-			// Build Part from generated steps
+			// Build Part from generated events
 			p := Part{
 				name:    meta.PartMetadata.Name,
 				group:   meta.PartMetadata.Group,
 				channel: meta.PartMetadata.Channel,
 				div:     meta.PartMetadata.Div,
+				drumMap: dm,
+				fill:    meta.PartMetadata.Fill,
+				seed:    meta.PartMetadata.Seed,
+				delay:   meta.PartMetadata.Delay,
+				strum:   partparser.Strum{Ticks: meta.PartMetadata.Strum, Down: meta.PartMetadata.StrumDown},
+				mono:    meta.PartMetadata.Mono,
+				overlap: meta.PartMetadata.Overlap,
 			}
-			for _, stepStr := range stepStrings {
-				p.steps = append(p.steps, step(stepStr))
+
+			env := generators.Env{
+				Dir:   filepath.Dir(s.Path),
+				Parts: s.partSteps,
+				Steps: p.stepEvents,
+			}
+			generate := func(params map[string]interface{}) (generators.Events, error) {
+				gen, err := factory(meta.PartMetadata, params, env)
+				if err != nil {
+					return generators.Events{}, err
+				}
+				return generators.Adapt(gen, env.Steps).GenerateEvents()
 			}
 
-			err = p.parseMIDI()
+			events, err := generate(meta.Params)
 			if err != nil {
 				return err
 			}
 
+			err = p.parseEvents(events)
+			if err != nil {
+				return err
+			}
+
+			if meta.Regen > 0 {
+				spec, _ := generators.GetSpec(meta.FuncType)
+				if !spec.Seeded() {
+					return fmt.Errorf("%s: regen requires a generator with a seed parameter", p.name)
+				}
+				var seed int64
+				if n, ok := meta.Params["seed"].(*metaparser.NumberNode); ok {
+					seed = int64(n.Value)
+				}
+				p.regen = &regen{
+					every: meta.Regen,
+					base:  seed,
+					seed:  seed,
+					generate: func(seed int64) (*Part, error) {
+						params := maps.Clone(meta.Params)
+						params["seed"] = &metaparser.NumberNode{Value: float64(seed)}
+						events, err := generate(params)
+						if err != nil {
+							return nil, err
+						}
+						return p.build(events)
+					},
+				}
+			}
+
 			s.Parts = append(s.Parts, &p)
 			s.Playable = append(s.Playable, &p)
 		}
@@ -150,6 +236,10 @@ func (s *Sequence) parse() error {
 
 	s.BPM = seqMeta.BPM
 	s.Loop = seqMeta.Loop
+	s.Chase = seqMeta.Chase
+	s.Launch = seqMeta.Launch
+	s.Smooth = seqMeta.Smooth
+	s.SyncTimeout = time.Duration(seqMeta.SyncTimeout) * time.Millisecond
 	s.Sync = seqMeta.Sync
 	s.SyncIn = seqMeta.SyncIn
 	s.VoiceOut = seqMeta.VoiceOut
@@ -162,6 +252,34 @@ func (s *Sequence) parse() error {
 	return nil
 }
 
+// partSteps returns a copy of the expanded steps of a previously parsed part.
+// Generators can run while the part's steps are being regenerated.
+func (s *Sequence) partSteps(name string) ([]string, bool) {
+	for _, p := range s.Parts {
+		if p.Name() == name {
+			p.mu.RLock()
+			defer p.mu.RUnlock()
+			return slices.Clone(p.expanded), true
+		}
+	}
+	return nil, false
+}
+
+// drumMap returns the drum map with the given name. Maps defined in the
+// sequence take precedence over built-in maps. An empty name returns no map.
+func (s *Sequence) drumMap(name string) (music.DrumMap, error) {
+	if name == "" {
+		return nil, nil
+	}
+	if dm, ok := s.DrumMaps[name]; ok {
+		return dm, nil
+	}
+	if dm, ok := music.BuiltinDrumMap(name); ok {
+		return dm, nil
+	}
+	return nil, fmt.Errorf("unknown drum map: %s", name)
+}
+
 func (s *Sequence) Warnings() []string {
 	var w []string
 	for _, p := range s.Playable {
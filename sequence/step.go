@@ -42,6 +42,12 @@ func (s *step) mult() (*int64, *int64, error) {
 	return &m, &modulo, nil
 }
 
+// notes returns the step with any multiplication factor removed
+func (s *step) notes() string {
+	n := regexp.MustCompile(`\*[[:digit:]]+(%[[:digit:]]+)?`).ReplaceAllString(string(*s), "")
+	return strings.Join(strings.Fields(n), " ")
+}
+
 func (s *step) names() []string {
 	var n []string
 	for _, f := range strings.Fields(string(*s)) {
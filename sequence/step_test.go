@@ -87,3 +87,25 @@ func TestStepModuloBehavior(t *testing.T) {
 		}
 	}
 }
+
+func TestStepNotes(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"c4 *8", "c4"},
+		{"c4:2   e4 *8%2", "c4:2 e4"},
+		{"*16", ""},
+		{"  CM7:4  ", "CM7:4"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			s := step(tt.input)
+			if got := s.notes(); got != tt.want {
+				t.Errorf("notes() = %q, want %q for input %q", got, tt.want, tt.input)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package sequence
+
+import (
+	"math"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+)
+
+var (
+	sequenceBlock = regexp.MustCompile("(?sm)^```beef\\.sequence(.*?)\n^```")
+	bpmField      = regexp.MustCompile(`(^|\s)bpm:\S*`)
+)
+
+// WriteBPM writes a tempo to the sequence's file, rounded to hundredths. It
+// replaces the bpm of the sequence block, or adds one, adding a sequence block
+// to the start of the file if there isn't one.
+func (s *Sequence) WriteBPM(bpm float64) error {
+	info, err := os.Stat(s.Path)
+	if err != nil {
+		return err
+	}
+	md, err := os.ReadFile(s.Path)
+	if err != nil {
+		return err
+	}
+
+	bpm = math.Round(bpm*100) / 100
+	field := "bpm:" + strconv.FormatFloat(bpm, 'f', -1, 64)
+
+	loc := sequenceBlock.FindSubmatchIndex(md)
+	var out []byte
+	switch {
+	case loc == nil:
+		out = slices.Concat([]byte("```beef.sequence\n"+field+"\n```\n\n"), md)
+	case bpmField.Match(md[loc[2]:loc[3]]):
+		block := bpmField.ReplaceAll(md[loc[2]:loc[3]], []byte("${1}"+field))
+		out = slices.Concat(md[:loc[2]], block, md[loc[3]:])
+	default:
+		out = slices.Concat(md[:loc[3]], []byte("\n"+field), md[loc[3]:])
+	}
+
+	if err := os.WriteFile(s.Path, out, info.Mode()); err != nil {
+		return err
+	}
+	s.BPM = bpm
+	return nil
+}
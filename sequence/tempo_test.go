@@ -0,0 +1,72 @@
+package sequence
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteBPM(t *testing.T) {
+	tests := []struct {
+		name string
+		md   string
+		bpm  float64
+		want string
+	}{
+		{
+			name: "replace",
+			md:   "# song\n\n```beef.sequence\nbpm:120\nloop:true\n```\n",
+			bpm:  132.5,
+			want: "# song\n\n```beef.sequence\nbpm:132.5\nloop:true\n```\n",
+		},
+		{
+			name: "same line",
+			md:   "```beef.sequence\nloop:true bpm:120\n```\n",
+			bpm:  96.004,
+			want: "```beef.sequence\nloop:true bpm:96\n```\n",
+		},
+		{
+			name: "add",
+			md:   "```beef.sequence\nloop:true\n```\n",
+			bpm:  140,
+			want: "```beef.sequence\nloop:true\nbpm:140\n```\n",
+		},
+		{
+			name: "no sequence block",
+			md:   "```beef.part name:a\nc4\n```\n",
+			bpm:  90,
+			want: "```beef.sequence\nbpm:90\n```\n\n```beef.part name:a\nc4\n```\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "song.md")
+			if err := os.WriteFile(path, []byte(tt.md), 0644); err != nil {
+				t.Fatal(err)
+			}
+			s, err := New(path)
+			if err != nil {
+				t.Fatalf("New() unexpected error: %v", err)
+			}
+			if err := s.WriteBPM(tt.bpm); err != nil {
+				t.Fatalf("WriteBPM() unexpected error: %v", err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("file = %q, want %q", got, tt.want)
+			}
+
+			// The file still parses, with the new tempo
+			s, err = New(path)
+			if err != nil {
+				t.Fatalf("New() after WriteBPM unexpected error: %v", err)
+			}
+			if want := float64(int(tt.bpm*100+0.5)) / 100; s.BPM != want {
+				t.Errorf("BPM = %g, want %g", s.BPM, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package sequence
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+
+	"github.com/odaacabeef/beefdown/midi"
+)
+
+// Timeline is a pass of an arrangement compiled into events by clock tick.
+// Playback walks it one clock tick at a time.
+type Timeline struct {
+	Events []Event // Sorted by tick, in the order they happen
+	Ticks  int     // Length of the pass
+}
+
+// EventType is what a timeline event does
+type EventType int
+
+const (
+	StepEvent    EventType = iota // A playable reaches a step
+	TriggerEvent                  // A part decides the messages of a step
+	MessageEvent                  // Messages are sent as they are
+)
+
+// Event happens on a clock tick of a timeline
+type Event struct {
+	Tick     int
+	Type     EventType
+	Playable Playable // The arrangement or part reaching a step, or the part triggered
+	Step     int
+
+	// Triggers are early enough for a part's earliest notes. The messages of
+	// the step are sent from Start, and any on or after End are dropped.
+	Start int
+	End   int
+
+	Messages [][]byte
+}
+
+// Compile builds the timeline of a pass of the arrangement, including any
+// nested arrangements. Each arrangement step lasts as long as its longest
+// part or arrangement.
+func (a *Arrangement) Compile() *Timeline {
+	t := &Timeline{}
+	t.Ticks = a.compile(t, 0)
+	slices.SortStableFunc(t.Events, func(a, b Event) int {
+		return cmp.Compare(a.Tick, b.Tick)
+	})
+	return t
+}
+
+// StepTick returns the tick arrangement a first reaches step i in the
+// timeline
+func (t *Timeline) StepTick(a *Arrangement, i int) (int, bool) {
+	for _, e := range t.Events {
+		if e.Type == StepEvent && e.Playable == a && e.Step == i {
+			return e.Tick, true
+		}
+	}
+	return 0, false
+}
+
+// Sounding returns a message event for each note still sounding at tick when
+// the timeline is played from the start, with the note on message and the
+// part playing it. Notes with conditions aren't counted since they're decided
+// when played. Off messages are counted before on messages on the same tick.
+func (t *Timeline) Sounding(tick int) []Event {
+	type on struct {
+		part *Part
+		msg  []byte
+	}
+	offs := map[int][][]byte{}
+	played := map[int][]on{}
+	for _, e := range t.Events {
+		if e.Tick >= tick {
+			break
+		}
+		switch e.Type {
+		case TriggerEvent:
+			p := e.Playable.(*Part)
+			src, i := p, e.Step
+			if p.source != nil {
+				src, i = p.source, i%len(p.source.StepMIDI)
+			}
+			s := src.StepMIDI[i]
+			for _, m := range s.On {
+				played[e.Start] = append(played[e.Start], on{p, m})
+			}
+			for k, msgs := range s.Sub {
+				for _, m := range msgs {
+					if e.Start+k < e.End {
+						played[e.Start+k] = append(played[e.Start+k], on{p, m})
+					}
+				}
+			}
+		case MessageEvent:
+			offs[e.Tick] = append(offs[e.Tick], e.Messages...)
+		}
+	}
+
+	sounding := map[[2]byte]on{}
+	for tk := range tick {
+		for _, m := range offs[tk] {
+			delete(sounding, [2]byte{m[0] & 0x0F, m[1]})
+		}
+		for _, o := range played[tk] {
+			switch {
+			case midi.IsNoteOff(o.msg):
+				delete(sounding, [2]byte{o.msg[0] & 0x0F, o.msg[1]})
+			case midi.IsNoteOn(o.msg):
+				sounding[[2]byte{o.msg[0] & 0x0F, o.msg[1]}] = o
+			}
+		}
+	}
+	notes := slices.SortedFunc(maps.Keys(sounding), func(a, b [2]byte) int {
+		return cmp.Or(cmp.Compare(a[0], b[0]), cmp.Compare(a[1], b[1]))
+	})
+	var events []Event
+	for _, n := range notes {
+		o := sounding[n]
+		events = append(events, Event{Tick: tick, Type: MessageEvent, Playable: o.part, Messages: [][]byte{o.msg}})
+	}
+	return events
+}
+
+// compile adds the arrangement's events from tick and returns its length
+func (a *Arrangement) compile(t *Timeline, tick int) int {
+	start := tick
+	for i, stepPlayables := range a.Playables {
+		t.Events = append(t.Events, Event{Tick: tick, Type: StepEvent, Playable: a, Step: i})
+		var length int
+		for _, playable := range stepPlayables {
+			switch p := playable.(type) {
+			case *Part:
+				p.compile(t, tick)
+				length = max(length, p.Ticks())
+			case *Arrangement:
+				length = max(length, p.compile(t, tick))
+			}
+		}
+		tick += length
+	}
+	return tick - start
+}
+
+// compile adds the part's events from tick
+func (p *Part) compile(t *Timeline, tick int) {
+	end := tick + p.Ticks()
+	for i, s := range p.StepMIDI {
+		start := tick + i*p.div
+		if start >= end {
+			break
+		}
+		// Sync parts only carry messages
+		if p.name != "" {
+			t.Events = append(t.Events,
+				Event{Tick: start, Type: StepEvent, Playable: p, Step: i},
+				Event{Tick: max(start-p.Lead(), tick), Type: TriggerEvent, Playable: p, Step: i, Start: start, End: end},
+			)
+		}
+		if len(s.Off) > 0 {
+			t.Events = append(t.Events, Event{Tick: start, Type: MessageEvent, Messages: s.Off})
+		}
+	}
+}
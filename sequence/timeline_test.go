@@ -0,0 +1,136 @@
+package sequence
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestArrangementCompile(t *testing.T) {
+	md := "```beef.part name:a div:8th\nc4:1\nd4<2\n```\n\n" +
+		"```beef.part name:b div:16th\ne4\n```\n\n" +
+		"```beef.arrangement name:inner\nb\nb\n```\n\n" +
+		"```beef.arrangement name:outer\na b\ninner\n```\n"
+	path := filepath.Join(t.TempDir(), "timeline.md")
+	if err := os.WriteFile(path, []byte(md), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(path)
+	if err != nil {
+		t.Fatalf("New() unexpected error: %v", err)
+	}
+	timeline := s.Arrangements[1].Compile()
+
+	// Steps last as long as their longest part or arrangement
+	if timeline.Ticks != 24+12 {
+		t.Errorf("Ticks = %d, want 36", timeline.Ticks)
+	}
+
+	type event struct {
+		tick  int
+		typ   EventType
+		name  string
+		step  int
+		start int
+	}
+	var got []event
+	for _, e := range timeline.Events {
+		var name string
+		if e.Playable != nil {
+			name = e.Playable.Name()
+		}
+		got = append(got, event{e.Tick, e.Type, name, e.Step, e.Start})
+	}
+	want := []event{
+		{0, StepEvent, "outer", 0, 0},
+		{0, StepEvent, "a", 0, 0},
+		{0, TriggerEvent, "a", 0, 0},
+		{0, StepEvent, "b", 0, 0},
+		{0, TriggerEvent, "b", 0, 0},
+		{10, TriggerEvent, "a", 1, 12}, // Early for d4<2
+		{11, MessageEvent, "", 0, 0},   // c4 off
+		{12, StepEvent, "a", 1, 0},
+		{24, StepEvent, "outer", 1, 0},
+		{24, StepEvent, "inner", 0, 0},
+		{24, StepEvent, "b", 0, 0},
+		{24, TriggerEvent, "b", 0, 24},
+		{30, StepEvent, "inner", 1, 0},
+		{30, StepEvent, "b", 0, 0},
+		{30, TriggerEvent, "b", 0, 30},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Events = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Events[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestTimelineCue(t *testing.T) {
+	md := "```beef.part name:a div:8th\nc4:1\nd4<2:1\n```\n\n" +
+		"```beef.part name:b div:16th\ne4:1\n```\n\n" +
+		"```beef.arrangement name:inner\nb\nb\n```\n\n" +
+		"```beef.arrangement name:outer\na b\ninner\n```\n"
+	path := filepath.Join(t.TempDir(), "cue.md")
+	if err := os.WriteFile(path, []byte(md), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(path)
+	if err != nil {
+		t.Fatalf("New() unexpected error: %v", err)
+	}
+	outer := s.Arrangements[1]
+	timeline := outer.Compile()
+
+	if tick, ok := timeline.StepTick(outer, 1); !ok || tick != 24 {
+		t.Errorf("StepTick(outer, 1) = %d, %v, want 24, true", tick, ok)
+	}
+	if tick, ok := timeline.StepTick(s.Arrangements[0], 1); !ok || tick != 30 {
+		t.Errorf("StepTick(inner, 1) = %d, %v, want 30, true", tick, ok)
+	}
+	if _, ok := timeline.StepTick(outer, 2); ok {
+		t.Errorf("StepTick(outer, 2) found a step past the end")
+	}
+
+	tests := []struct {
+		tick  int
+		notes []byte
+	}{
+		{0, nil},
+		{11, []byte{60, 62}}, // c4 ends on tick 11, d4 started early
+		{12, []byte{62}},
+		{24, nil},
+	}
+	for _, tt := range tests {
+		var notes []byte
+		for _, e := range timeline.Sounding(tt.tick) {
+			if e.Playable.Name() != "a" {
+				t.Errorf("Sounding(%d) part = %s, want a", tt.tick, e.Playable.Name())
+			}
+			notes = append(notes, e.Messages[0][1])
+		}
+		if string(notes) != string(tt.notes) {
+			t.Errorf("Sounding(%d) = %v, want %v", tt.tick, notes, tt.notes)
+		}
+	}
+
+	var names []string
+	for _, p := range outer.Parts() {
+		names = append(names, p.Name())
+	}
+	if strings.Join(names, " ") != "a b" {
+		t.Errorf("Parts() = %v, want [a b]", names)
+	}
+
+	outer.MoveCue(1)
+	if c := outer.Cue(); c == nil || *c != 0 {
+		t.Errorf("MoveCue(1) from no cue = %v, want step 0", c)
+	}
+	outer.MoveCue(5)
+	if c := outer.Cue(); c == nil || *c != 1 {
+		t.Errorf("MoveCue(5) = %v, want last step 1", c)
+	}
+}
@@ -0,0 +1,113 @@
+package sequence
+
+import (
+	"hash/fnv"
+	"maps"
+	"math/rand"
+	"slices"
+
+	partparser "github.com/odaacabeef/beefdown/sequence/parsers/part"
+)
+
+// trig is a note (or chord) that only plays when its condition passes
+type trig struct {
+	condition partparser.Condition
+	on        [][]byte
+	sub       map[int][][]byte
+}
+
+// add adds note on messages, and any messages after the start of the step,
+// to the step. They're added as a trig if they have a condition.
+func (s *partStep) add(c partparser.Condition, on [][]byte, sub map[int][][]byte) {
+	if !c.Always() {
+		s.Trigs = append(s.Trigs, trig{condition: c, on: on, sub: sub})
+		return
+	}
+	s.On = append(s.On, on...)
+	s.Sub = mergeSub(s.Sub, sub)
+}
+
+// mergeSub adds the messages of b to a by tick and returns a. The message
+// slices of a aren't modified, only replaced.
+func mergeSub(a, b map[int][][]byte) map[int][][]byte {
+	if len(b) > 0 && a == nil {
+		a = map[int][][]byte{}
+	}
+	for tick, msgs := range b {
+		a[tick] = slices.Concat(a[tick], msgs)
+	}
+	return a
+}
+
+// Trigger returns the messages to send for step i: on messages for the start
+// of the step and messages for ticks after it. Trigs whose conditions fail
+// are left out. Triggering step 0 starts a new pass of the part.
+func (p *Part) Trigger(i int, fill bool) ([][]byte, map[int][][]byte) {
+	if p.source != nil {
+		return p.source.Trigger(i%len(p.source.StepMIDI), fill)
+	}
+
+	p.trigMu.Lock()
+	defer p.trigMu.Unlock()
+
+	if i == 0 {
+		p.passes++
+	}
+	s := p.StepMIDI[i]
+	if len(s.Trigs) == 0 {
+		return s.On, s.Sub
+	}
+
+	on := slices.Clone(s.On)
+	sub := maps.Clone(s.Sub)
+	for _, t := range s.Trigs {
+		if p.plays(t.condition, fill) {
+			on = append(on, t.on...)
+			sub = mergeSub(sub, t.sub)
+		}
+	}
+	return on, sub
+}
+
+// plays decides a condition for the current pass
+func (p *Part) plays(c partparser.Condition, fill bool) bool {
+	if p.rng == nil {
+		p.resetRand()
+	}
+
+	play := true
+	// Always roll so the random sequence doesn't depend on other conditions
+	if c.Probability > 0 {
+		play = p.rng.Intn(100) < c.Probability
+	}
+	if c.Cycle > 0 && (max(p.passes, 1)-1)%c.Of+1 != c.Cycle {
+		play = false
+	}
+	switch c.Fill {
+	case partparser.FillOnly:
+		play = play && fill
+	case partparser.NotFill:
+		play = play && !fill
+	}
+	return play
+}
+
+// resetRand seeds the part's random numbers from its seed and name, so parts
+// sharing a seed don't make the same choices
+func (p *Part) resetRand() {
+	h := fnv.New64a()
+	h.Write([]byte(p.name))
+	p.rng = rand.New(rand.NewSource(p.seed ^ int64(h.Sum64())))
+}
+
+// resetPasses restarts the part's passes when playback starts
+func (p *Part) resetPasses() {
+	p.trigMu.Lock()
+	p.passes = 0
+	p.resetRand()
+	p.trigMu.Unlock()
+
+	if p.regen != nil {
+		p.regen.reset()
+	}
+}
@@ -0,0 +1,153 @@
+package sequence
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/odaacabeef/beefdown/midi"
+)
+
+func TestPartTrigger(t *testing.T) {
+	p := Part{
+		name:    "trigs",
+		channel: 1,
+		div:     24,
+		steps:   []step{"c4 e4[1:2]", "g4[fill] a4[!fill]"},
+	}
+	if err := p.parseMIDI(); err != nil {
+		t.Fatalf("parseMIDI() unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		step int
+		fill bool
+		want int // Number of notes played
+	}{
+		{0, false, 2}, // Pass 1 plays e4
+		{1, false, 1}, // a4
+		{0, false, 1}, // Pass 2 doesn't play e4
+		{1, true, 1},  // g4
+		{0, false, 2}, // Pass 3 plays e4 again
+	}
+	for i, tt := range tests {
+		on, _ := p.Trigger(tt.step, tt.fill)
+		if got := len(on); got != tt.want {
+			t.Errorf("trigger %d: played %d notes, want %d", i, got, tt.want)
+		}
+	}
+}
+
+func TestPartTriggerProbability(t *testing.T) {
+	p := Part{
+		name:    "probability",
+		channel: 1,
+		div:     24,
+		steps:   []step{"c4?25"},
+		seed:    3,
+	}
+	if err := p.parseMIDI(); err != nil {
+		t.Fatalf("parseMIDI() unexpected error: %v", err)
+	}
+
+	run := func() []bool {
+		p.resetPasses()
+		var played []bool
+		for range 400 {
+			on, _ := p.Trigger(0, false)
+			played = append(played, len(on) > 0)
+		}
+		return played
+	}
+
+	first := run()
+	var count int
+	for _, played := range first {
+		if played {
+			count++
+		}
+	}
+	if count < 60 || count > 140 {
+		t.Errorf("c4?25 played %d of 400 times", count)
+	}
+
+	// Playback starting again makes the same choices
+	second := run()
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("pass %d differs after reset", i+1)
+		}
+	}
+}
+
+func TestPartTriggerRatchet(t *testing.T) {
+	p := Part{
+		name:    "ratchet",
+		channel: 1,
+		div:     24,
+		steps:   []step{"c1x4:decay"},
+	}
+	if err := p.parseMIDI(); err != nil {
+		t.Fatalf("parseMIDI() unexpected error: %v", err)
+	}
+
+	on, sub := p.Trigger(0, false)
+	if len(on) != 1 || on[0][2] != 100 {
+		t.Fatalf("first hit = %v, want velocity 100", on)
+	}
+	for tick, velocity := range map[int]byte{6: 75, 12: 50, 18: 25} {
+		msgs := sub[tick]
+		if len(msgs) != 2 || !midi.IsNoteOff(msgs[0]) || !midi.IsNoteOn(msgs[1]) {
+			t.Fatalf("tick %d: got %v, want note off then note on", tick, msgs)
+		}
+		if msgs[1][2] != velocity {
+			t.Errorf("tick %d: velocity %d, want %d", tick, msgs[1][2], velocity)
+		}
+	}
+	if len(sub) != 3 {
+		t.Errorf("got messages on %d ticks, want 3", len(sub))
+	}
+}
+
+func TestGeneratedModifiers(t *testing.T) {
+	md := "```beef.part name:src\nc4?50\nc1x3\n```\n\n" +
+		"```beef.gen.markov\nname:gen\nparts:src\nlength:2\norder:2\n```\n"
+	path := filepath.Join(t.TempDir(), "gen.md")
+	if err := os.WriteFile(path, []byte(md), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(path)
+	if err != nil {
+		t.Fatalf("New() unexpected error: %v", err)
+	}
+	p := s.Parts[1]
+
+	// Conditions in generated step text are decided during playback
+	first := p.StepMIDI[0]
+	if len(first.On) != 0 || len(first.Trigs) != 1 || first.Trigs[0].condition.Probability != 50 {
+		t.Errorf("StepMIDI[0] = %+v, want c4 as a trig with a 50%% chance", first)
+	}
+
+	// So are ratchets
+	second := p.StepMIDI[1]
+	if len(second.On) != 1 || second.On[0][1] != 24 {
+		t.Errorf("StepMIDI[1].On = %v, want c1", second.On)
+	}
+	for _, tick := range []int{8, 16} {
+		msgs := second.Sub[tick]
+		if len(msgs) != 1 || !midi.IsNoteOn(msgs[0]) || msgs[0][1] != 24 {
+			t.Errorf("StepMIDI[1].Sub[%d] = %v, want c1", tick, msgs)
+		}
+	}
+	if len(second.Sub) != 2 {
+		t.Errorf("StepMIDI[1].Sub = %v, want hits on 2 ticks", second.Sub)
+	}
+
+	// Each hit ends on the tick before the next
+	for _, tick := range []int{31, 39} {
+		msgs := p.offMessages[tick]
+		if len(msgs) != 1 || !midi.IsNoteOff(msgs[0]) || msgs[0][1] != 24 {
+			t.Errorf("offMessages[%d] = %v, want c1 off", tick, msgs)
+		}
+	}
+}
@@ -25,6 +25,7 @@ type model struct {
 
 	selected coordinates
 	playing  *coordinates
+	queued   *coordinates // Playable queued to launch
 	mu       sync.RWMutex // Mutex for protecting shared state
 
 	viewport *viewport
@@ -32,9 +33,10 @@ type model struct {
 	playStart *time.Time
 	playMu    sync.RWMutex // Mutex for protecting playStart
 
-	playCh  chan struct{}
-	stopCh  chan struct{}
-	clockCh chan struct{}
+	playCh   chan struct{}
+	stopCh   chan struct{}
+	clockCh  chan struct{}
+	launchCh chan struct{}
 
 	errs  []error
 	errMu sync.RWMutex // Mutex for protecting errs
@@ -83,7 +85,7 @@ func (m *model) setDevicePlaybackConfig() {
 	if m.device != nil {
 		_, playables := m.getCurrentGroup()
 		m.device.SetCurrentPlayable(playables[m.selected.x])
-		m.device.SetPlaybackConfig(m.sequence.BPM, m.sequence.Loop, m.sequence.Sync)
+		m.device.SetPlaybackConfig(m.sequence.BPM, m.sequence.Loop, m.sequence.Chase, m.sequence.Smooth, m.sequence.Launch, m.sequence.Sync, m.sequence.SyncTimeout)
 	}
 }
 
@@ -205,10 +207,22 @@ func (m *model) getCurrentGroup() (string, []sequence.Playable) {
 	return groupName, playables
 }
 
+// selectedPlayable returns the selected playable, or nil if there isn't one
+func (m *model) selectedPlayable() sequence.Playable {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, playables := m.getCurrentGroup()
+	if m.selected.x < 0 || m.selected.x >= len(playables) {
+		return nil
+	}
+	return playables[m.selected.x]
+}
+
 func (m *model) Init() tea.Cmd {
 	return tea.Batch(
 		listenForDevicePlay(m.playCh),
 		listenForDeviceClock(m.clockCh),
+		listenForDeviceLaunch(m.launchCh),
 		listenForDeviceErrors(m.device.ErrorsCh()),
 	)
 }
@@ -237,6 +251,7 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.mu.Lock()
 		m.playing = nil
+		m.queued = nil
 		m.mu.Unlock()
 		m.playMu.Lock()
 		m.playStart = nil
@@ -246,6 +261,16 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case deviceClock:
 		return m, listenForDeviceClock(m.clockCh)
 
+	case deviceLaunch:
+		// A queued playable launched in place of the one playing
+		m.mu.Lock()
+		if m.queued != nil {
+			m.playing = m.queued
+			m.queued = nil
+		}
+		m.mu.Unlock()
+		return m, listenForDeviceLaunch(m.launchCh)
+
 	case deviceError:
 		m.errMu.Lock()
 		m.errs = append(m.errs, msg)
@@ -260,13 +285,13 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		switch msg.String() {
 
 		case "ctrl+c", "q":
-			if m.device.Playing() && m.device.CancelF != nil {
+			if !m.device.Stopped() && m.device.CancelF != nil {
 				m.device.CancelF()
 			}
 			return m, tea.Quit
 
 		case "R":
-			if m.device.Playing() {
+			if !m.device.Stopped() {
 				m.device.CancelF()
 			}
 			err := m.loadSequence(m.sequence.Path)
@@ -406,6 +431,41 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			m.mu.Unlock()
 
+		case "[", "]":
+			// Move the step playback starts from
+			if a, ok := m.selectedPlayable().(*sequence.Arrangement); ok {
+				if msg.String() == "[" {
+					a.MoveCue(-1)
+				} else {
+					a.MoveCue(1)
+				}
+			}
+
+		case "c":
+			// Start playback from the first step again
+			if a, ok := m.selectedPlayable().(*sequence.Arrangement); ok {
+				a.ClearCue()
+			}
+
+		case "f":
+			m.device.ToggleFill()
+
+		case "x":
+			// Freeze or unfreeze the seed of a regenerating part
+			if p, ok := m.selectedPlayable().(*sequence.Part); ok {
+				p.ToggleFreeze()
+			}
+
+		case "X":
+			// Re-roll the seed of a regenerating part
+			if p, ok := m.selectedPlayable().(*sequence.Part); ok {
+				if err := p.Reroll(m.device.Stopped()); err != nil {
+					m.errMu.Lock()
+					m.errs = append(m.errs, err)
+					m.errMu.Unlock()
+				}
+			}
+
 		case " ":
 			if m.sequence.Sync == "follower" {
 				break
@@ -418,6 +478,111 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// Device is playing or in unknown state, stop it
 				m.device.StopSub.Pub()
 			}
+
+		case "enter":
+			// Launch the selected playable, at the next launch boundary if
+			// something is playing
+			p := m.selectedPlayable()
+			if p == nil {
+				break
+			}
+			if m.device.Stopped() {
+				if m.sequence.Sync != "follower" {
+					m.device.PlaySub.Pub()
+				}
+				break
+			}
+			m.device.Queue(p)
+			m.mu.Lock()
+			queued := m.selected
+			m.queued = &queued
+			m.mu.Unlock()
+
+		case "L":
+			// Play the selected playable alongside the one playing, or stop
+			// it if it already is
+			if p := m.selectedPlayable(); p != nil && !m.device.Stopped() {
+				m.device.ToggleLayer(p)
+			}
+
+		case "m", "s":
+			// Mute or solo the selected part, or the parts of the selected
+			// arrangement
+			var parts []*sequence.Part
+			switch p := m.selectedPlayable().(type) {
+			case *sequence.Part:
+				parts = []*sequence.Part{p}
+			case *sequence.Arrangement:
+				parts = p.Parts()
+			}
+			if len(parts) == 0 {
+				break
+			}
+			if msg.String() == "m" {
+				m.device.ToggleMute(parts...)
+			} else {
+				m.device.ToggleSolo(parts...)
+			}
+
+		case "M", "S":
+			// Mute or solo the channel of the selected part
+			if p, ok := m.selectedPlayable().(*sequence.Part); ok {
+				if msg.String() == "M" {
+					m.device.ToggleChannelMute(p.Channel())
+				} else {
+					m.device.ToggleChannelSolo(p.Channel())
+				}
+			}
+
+		case "-", "=", "_", "+", "t":
+			if m.sequence.Sync == "follower" {
+				break
+			}
+			// Nudge the tempo in fine or coarse steps, or tap it
+			var err error
+			switch msg.String() {
+			case "-":
+				err = m.device.NudgeBPM(-0.1)
+			case "=":
+				err = m.device.NudgeBPM(0.1)
+			case "_":
+				err = m.device.NudgeBPM(-1)
+			case "+":
+				err = m.device.NudgeBPM(1)
+			case "t":
+				err = m.device.Tap(time.Now())
+			}
+			if err != nil {
+				m.errMu.Lock()
+				m.errs = append(m.errs, err)
+				m.errMu.Unlock()
+			}
+
+		case "W":
+			// Write the live tempo to the sequence file
+			if m.sequence.Sync == "follower" {
+				break
+			}
+			if err := m.sequence.WriteBPM(m.device.BPM()); err != nil {
+				m.errMu.Lock()
+				m.errs = append(m.errs, err)
+				m.errMu.Unlock()
+			}
+
+		case "!":
+			// Reset every channel, for stuck notes
+			m.device.Panic()
+
+		case "p":
+			if m.sequence.Sync == "follower" {
+				break
+			}
+			// Toggle pause, keeping the playback position
+			if m.device.Paused() {
+				m.device.Continue()
+			} else {
+				m.device.Pause()
+			}
 		}
 	}
 
@@ -436,7 +601,11 @@ func (m *model) View() string {
 
 	header := fmt.Sprintf("%s;", m.sequence.Path)
 	if m.sequence.Sync != "follower" {
-		header += fmt.Sprintf(" bpm: %f; loop: %v;", m.sequence.BPM, m.sequence.Loop)
+		header += fmt.Sprintf(" bpm: %f; loop: %v;", m.device.BPM(), m.sequence.Loop)
+	} else if bpm := m.device.LeaderBPM(); bpm > 0 {
+		header += fmt.Sprintf(" bpm: %.2f (leader);", bpm)
+	} else {
+		header += " bpm: - (leader);"
 	}
 	header += fmt.Sprintf(" sync: %s", m.sequence.Sync)
 	header = st.sequence().Render(header)
@@ -447,7 +616,11 @@ func (m *model) View() string {
 		t = time.Since(*m.playStart).Round(time.Second).String()
 	}
 	m.playMu.RUnlock()
-	header += st.state().Render(fmt.Sprintf("state: %s; goroutines: %d; time: %s", m.device.State(), runtime.NumGoroutine(), t))
+	fill := "off"
+	if m.device.Fill() {
+		fill = "on"
+	}
+	header += st.state().Render(fmt.Sprintf("state: %s; fill: %s; goroutines: %d; time: %s", m.device.State(), fill, runtime.NumGoroutine(), t))
 
 	m.errMu.RLock()
 	if len(m.errs) > 0 {
@@ -485,7 +658,13 @@ func (m *model) View() string {
 			}
 			selected := pIdx == m.selected.x && gIdx == m.selected.y
 			playing := m.playing != nil && pIdx == m.playing.x && gIdx == m.playing.y
-			playables = append(playables, st.playable(selected, playing).Render(p.Title()+steps))
+			playing = playing || m.device.Layered(p)
+			queued := m.queued != nil && pIdx == m.queued.x && gIdx == m.queued.y
+			pst := st.playable(selected, playing, queued)
+			if part, ok := p.(*sequence.Part); ok && !m.device.Audible(part) {
+				pst = pst.Faint(true)
+			}
+			playables = append(playables, pst.Render(p.Title()+steps))
 		}
 		// group name displayed vertically
 		groupNames = append(groupNames, st.groupName().Render(strings.Join(strings.Split(groupName, ""), "\n")))
@@ -34,7 +34,7 @@ func (s style) header(width int) lipgloss.Style {
 		Width(width)
 }
 
-func (s style) playable(selected, playing bool) lipgloss.Style {
+func (s style) playable(selected, playing, queued bool) lipgloss.Style {
 	base := lipgloss.NewStyle().
 		Padding(0, 1).
 		Margin(1)
@@ -42,6 +42,9 @@ func (s style) playable(selected, playing bool) lipgloss.Style {
 	switch {
 	case playing:
 		return base.Border(lipgloss.DoubleBorder())
+	case queued:
+		return base.Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("11"))
 	case selected:
 		return base.Border(lipgloss.NormalBorder())
 	}
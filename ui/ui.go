@@ -25,6 +25,7 @@ func initialModel(sequencePath string) (*model, error) {
 		playCh:   make(chan struct{}),
 		stopCh:   make(chan struct{}),
 		clockCh:  make(chan struct{}),
+		launchCh: make(chan struct{}),
 	}
 
 	err := m.loadSequence(sequencePath)
@@ -42,6 +43,7 @@ func initialModel(sequencePath string) (*model, error) {
 	m.device.PlaySub.Sub("ui", m.playCh)
 	m.device.StopSub.Sub("ui", m.stopCh)
 	m.device.ClockSub.Sub("ui", m.clockCh)
+	m.device.LaunchSub.Sub("ui", m.launchCh)
 	m.setDevicePlaybackConfig()
 
 	return &m, nil
@@ -71,6 +73,14 @@ func listenForDeviceClock(c chan struct{}) tea.Cmd {
 	}
 }
 
+type deviceLaunch struct{}
+
+func listenForDeviceLaunch(c chan struct{}) tea.Cmd {
+	return func() tea.Msg {
+		return deviceLaunch(<-c)
+	}
+}
+
 type deviceError error
 
 func listenForDeviceErrors(err chan error) tea.Cmd {